@@ -0,0 +1,241 @@
+package gqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token is an access token that is sent in the Authorization header of requests.
+type Token struct {
+	AccessToken string
+	// TokenType is the type of the token in the Authorization header, which defaults to Bearer.
+	TokenType string
+	// Expiry is the time at which the token expires. A token with a zero Expiry does not expire.
+	Expiry time.Time
+}
+
+// authorization returns the value of the Authorization header for the token.
+func (t *Token) authorization() string {
+	tokenType := t.TokenType
+	if tokenType == "" || strings.EqualFold(tokenType, "bearer") {
+		tokenType = "Bearer"
+	}
+	return tokenType + " " + t.AccessToken
+}
+
+// TokenSource fetches a new Token every time Token is called. The Client caches the token.
+type TokenSource interface {
+	Token(ctx context.Context) (*Token, error)
+}
+
+// WithTokenSource sets the Authorization header of every Request to a token from the TokenSource.
+// The token is cached until it expires, and is refreshed in the background when it expires within
+// refreshBefore. When the server rejects the token with a 401 status code or a GraphQL error with
+// the UNAUTHENTICATED code, a new token is fetched and the request is sent once more. To detect the
+// GraphQL error, the body of every response is read in memory, except for Client.DoStream, which
+// only detects the 401 status code. A Request with its own Authorization header is sent as is.
+//  NewClient(endpoint, WithTokenSource(&ClientCredentials{
+//      TokenURL:     "https://auth.example.com/oauth/token",
+//      ClientID:     id,
+//      ClientSecret: secret,
+//  }, time.Minute))
+func WithTokenSource(source TokenSource, refreshBefore time.Duration) ClientOption {
+	return func(client *Client) {
+		client.tokens = &tokenAuth{source: source, refreshBefore: refreshBefore}
+	}
+}
+
+// tokenAuth caches the tokens of a TokenSource.
+type tokenAuth struct {
+	source        TokenSource
+	refreshBefore time.Duration
+
+	mu    sync.Mutex
+	token *Token
+	fetch *tokenFetch
+}
+
+// tokenFetch is a token that is being fetched, and its result when done is closed.
+type tokenFetch struct {
+	done  chan struct{}
+	token *Token
+	err   error
+}
+
+// get returns the cached token, or fetches a new token when there is no valid token or when the
+// cached token is the rejected token. The token is fetched once for concurrent callers, and a caller
+// stops waiting for it when its Context is done.
+func (a *tokenAuth) get(ctx context.Context, rejected *Token) (*Token, error) {
+	a.mu.Lock()
+	if a.token != nil && a.token != rejected {
+		token := a.token
+		remaining := time.Until(token.Expiry)
+		if token.Expiry.IsZero() || remaining > a.refreshBefore {
+			a.mu.Unlock()
+			return token, nil
+		}
+		if remaining > 0 {
+			// Refresh the token in the background. The cached token is kept when it fails, and
+			// is refreshed again by the next request.
+			a.startFetch(ctx)
+			a.mu.Unlock()
+			return token, nil
+		}
+	}
+	fetch := a.startFetch(ctx)
+	a.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+		if fetch.err != nil {
+			return nil, fetch.err
+		}
+		return fetch.token, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startFetch fetches a new token, unless a token is being fetched already, and must be called with
+// a.mu held. The token is fetched with the values of the Context, but is not cancelled with it, as
+// other callers may wait for the same token.
+func (a *tokenAuth) startFetch(ctx context.Context) *tokenFetch {
+	if a.fetch != nil {
+		return a.fetch
+	}
+	fetch := &tokenFetch{done: make(chan struct{})}
+	a.fetch = fetch
+	go func() {
+		ctx, cancel := context.WithTimeout(detachedContext{ctx}, backgroundTimeout)
+		defer cancel()
+		token, err := a.source.Token(ctx)
+
+		a.mu.Lock()
+		if err != nil {
+			fetch.err = fmt.Errorf("token: %w", err)
+		} else {
+			a.token, fetch.token = token, token
+		}
+		a.fetch = nil
+		a.mu.Unlock()
+		close(fetch.done)
+	}()
+	return fetch
+}
+
+// rejected reports whether the server rejected the token of the request, and closes the body of
+// the response when it did. With readBody, the body of the response is read in memory and replaced
+// to find an UNAUTHENTICATED error, otherwise only the status code is checked.
+func (a *tokenAuth) rejected(httpResp *http.Response, codec Codec, readBody bool) (bool, error) {
+	if httpResp.StatusCode == http.StatusUnauthorized {
+		return true, httpResp.Body.Close()
+	}
+	if !readBody {
+		return false, nil
+	}
+
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if cerr := httpResp.Body.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return false, fmt.Errorf("read body: %w", err)
+	}
+	httpResp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var errs errorsResponse
+	if err := codec.NewDecoder(bytes.NewReader(body)).Decode(&errs); err != nil {
+		return false, nil
+	}
+	for _, gqlErr := range errs.Errors {
+		if gqlErr.Extensions["code"] == "UNAUTHENTICATED" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ClientCredentials is a TokenSource that fetches tokens with the OAuth 2.0 client credentials grant.
+// The client id and secret are sent with basic authentication.
+type ClientCredentials struct {
+	// TokenURL is the url of the token endpoint.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Scopes are the requested scopes, if any.
+	Scopes []string
+	// Params are additional parameters of the token request, like an audience.
+	Params url.Values
+	// HTTPClient is used to request the token, which defaults to http.DefaultClient.
+	HTTPClient HTTPClient
+}
+
+// tokenResponse is the response of a token endpoint.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token requests a new token from the token endpoint.
+func (cc *ClientCredentials) Token(ctx context.Context) (*Token, error) {
+	params := url.Values{}
+	for name, values := range cc.Params {
+		params[name] = values
+	}
+	params.Set("grant_type", "client_credentials")
+	if len(cc.Scopes) > 0 {
+		params.Set("scope", strings.Join(cc.Scopes, " "))
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, cc.TokenURL, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create token request: %w", err)
+	}
+	httpReq = httpReq.WithContext(ctx)
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.SetBasicAuth(url.QueryEscape(cc.ClientID), url.QueryEscape(cc.ClientSecret))
+
+	httpClient := cc.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("do token request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp tokenResponse
+	decodeErr := json.NewDecoder(httpResp.Body).Decode(&resp)
+	switch {
+	case resp.Error != "" && resp.ErrorDescription != "":
+		return nil, fmt.Errorf("token endpoint: %s: %s", resp.Error, resp.ErrorDescription)
+	case resp.Error != "":
+		return nil, fmt.Errorf("token endpoint: %s", resp.Error)
+	case httpResp.StatusCode != http.StatusOK:
+		return nil, NewHTTPError(httpResp.StatusCode)
+	case decodeErr != nil:
+		return nil, fmt.Errorf("decode token response: %w", decodeErr)
+	case resp.AccessToken == "":
+		return nil, errors.New("token endpoint: no access token")
+	}
+
+	token := &Token{AccessToken: resp.AccessToken, TokenType: resp.TokenType}
+	if resp.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	}
+	return token, nil
+}
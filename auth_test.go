@@ -0,0 +1,259 @@
+package gqlclient_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteAuth struct {
+	suite.Suite
+	httpClient  *mocks.HTTPClient
+	tokenServer *httptest.Server
+	mu          sync.Mutex
+	tokens      int
+	expiresIn   int
+}
+
+func TestSuiteAuth(t *testing.T) {
+	s := SuiteAuth{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteAuth) SetupTest() {
+	s.tokens = 0
+	s.expiresIn = 3600
+	s.tokenServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		s.True(ok)
+		s.NoError(r.ParseForm())
+		w.Header().Set("Content-Type", "application/json")
+		if id != "id" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": "invalid_client", "error_description": "unknown client"}`))
+			return
+		}
+		s.Equal("client_credentials", r.PostForm.Get("grant_type"))
+		s.Equal("read write", r.PostForm.Get("scope"))
+		s.Equal("api", r.PostForm.Get("audience"))
+
+		s.mu.Lock()
+		s.tokens++
+		token := fmt.Sprintf("t%d", s.tokens)
+		s.mu.Unlock()
+		_, _ = fmt.Fprintf(w, `{"access_token": %q, "token_type": "bearer", "expires_in": %d}`, token, s.expiresIn)
+	}))
+}
+
+func (s *SuiteAuth) TearDownTest() {
+	s.tokenServer.Close()
+}
+
+// reset starts a new token server.
+func (s *SuiteAuth) reset() {
+	s.TearDownTest()
+	s.SetupTest()
+}
+
+// source returns a ClientCredentials TokenSource for the token server.
+func (s *SuiteAuth) source() *gql.ClientCredentials {
+	return &gql.ClientCredentials{
+		TokenURL:     s.tokenServer.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+		Params:       map[string][]string{"audience": {"api"}},
+	}
+}
+
+// client returns a client with the TokenSource, whose server responds with the response of respond
+// for the Authorization header of the request.
+func (s *SuiteAuth) client(source gql.TokenSource, respond func(authorization string) (int, string)) *gql.Client {
+	s.httpClient = new(mocks.HTTPClient)
+	s.httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) *http.Response {
+			status, body := respond(req.Header.Get("Authorization"))
+			return &http.Response{
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+				StatusCode: status,
+			}
+		}, nil)
+	return gql.NewClient("test", gql.WithHTTPClient(s.httpClient), gql.WithTokenSource(source, time.Minute))
+}
+
+// accept returns a respond function that only accepts the authorization, and responds to other
+// authorizations with the status code and body.
+func accept(authorization string, status int, body string) func(string) (int, string) {
+	return func(got string) (int, string) {
+		if got != authorization {
+			return status, body
+		}
+		return http.StatusOK, `{"data": {"viewer": {"name": "Bob"}}}`
+	}
+}
+
+func (s *SuiteAuth) TestCachedToken() {
+	c := s.client(s.source(), accept("Bearer t1", http.StatusUnauthorized, ""))
+	for i := 0; i < 3; i++ {
+		var resp struct {
+			Viewer struct {
+				Name string
+			}
+		}
+		s.Require().NoError(c.Do(gql.NewRequest(`{ viewer { name } }`), &resp))
+		s.Equal("Bob", resp.Viewer.Name)
+	}
+	s.Equal(1, s.tokens)
+
+	// A Request with its own Authorization header is sent as is.
+	err := c.Do(gql.NewRequest(`{ viewer { name } }`, gql.WithHeader("authorization", "Basic abc")), nil)
+	s.Equal(gql.NewHTTPError(http.StatusUnauthorized), err)
+	s.Equal(1, s.tokens)
+}
+
+func (s *SuiteAuth) TestRejectedToken() {
+	tests := []struct {
+		status int
+		body   string
+	}{
+		{status: http.StatusUnauthorized, body: ""},
+		{status: http.StatusOK, body: `{"errors": [{"message": "expired", "extensions": {"code": "UNAUTHENTICATED"}}]}`},
+	}
+	for _, test := range tests {
+		s.reset()
+		c := s.client(s.source(), accept("Bearer t2", test.status, test.body))
+		s.NoError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+		s.Equal(2, s.tokens)
+		s.httpClient.AssertNumberOfCalls(s.T(), "Do", 2)
+	}
+
+	// The request is only sent once more.
+	s.reset()
+	c := s.client(s.source(), accept("Bearer t3", http.StatusUnauthorized, ""))
+	s.Equal(gql.NewHTTPError(http.StatusUnauthorized), c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+	s.Equal(2, s.tokens)
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 2)
+
+	// Other errors are not retried.
+	s.reset()
+	c = s.client(s.source(), func(string) (int, string) {
+		return http.StatusOK, `{"errors": [{"message": "not allowed"}]}`
+	})
+	s.EqualError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil), "graphql: not allowed")
+	s.Equal(1, s.tokens)
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 1)
+}
+
+func (s *SuiteAuth) TestRejectedTokenStream() {
+	stream := func(c *gql.Client) error {
+		return c.DoStream(gql.NewRequest(`{ viewers { name } }`), "data.viewers", func(int, gql.Decoder) error {
+			return nil
+		})
+	}
+
+	// Streamed responses are not read in memory, so only a 401 status code rejects the token.
+	c := s.client(s.source(), accept("Bearer t2", http.StatusUnauthorized, ""))
+	s.NoError(stream(c))
+	s.Equal(2, s.tokens)
+
+	s.reset()
+	body := `{"errors": [{"message": "expired", "extensions": {"code": "UNAUTHENTICATED"}}]}`
+	c = s.client(s.source(), accept("Bearer t2", http.StatusOK, body))
+	s.EqualError(stream(c), "graphql: expired")
+	s.Equal(1, s.tokens)
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 1)
+}
+
+func (s *SuiteAuth) TestRefresh() {
+	// The token expires within the refresh duration, so it is refreshed in the background.
+	s.expiresIn = 30
+	c := s.client(s.source(), func(authorization string) (int, string) {
+		return http.StatusOK, `{"data": {"viewer": {"name": "Bob"}}}`
+	})
+	s.NoError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+	s.NoError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+	s.Eventually(func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.tokens == 2
+	}, time.Second, 10*time.Millisecond)
+
+	// Expired tokens are replaced before the request is sent.
+	s.reset()
+	c = s.client(&expiringSource{source: s.source()}, accept("Bearer t2", http.StatusUnauthorized, ""))
+	s.NoError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+	s.Equal(gql.NewHTTPError(http.StatusUnauthorized), c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+	s.Equal(4, s.tokens)
+}
+
+// expiringSource returns tokens that are already expired.
+type expiringSource struct {
+	source gql.TokenSource
+}
+
+func (e *expiringSource) Token(ctx context.Context) (*gql.Token, error) {
+	token, err := e.source.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token.Expiry = time.Now().Add(-time.Second)
+	return token, nil
+}
+
+// blockingSource returns tokens once release is closed, and counts its calls.
+type blockingSource struct {
+	release chan struct{}
+	calls   chan struct{}
+}
+
+func (b *blockingSource) Token(ctx context.Context) (*gql.Token, error) {
+	b.calls <- struct{}{}
+	<-b.release
+	return &gql.Token{AccessToken: "t1"}, ctx.Err()
+}
+
+func (s *SuiteAuth) TestConcurrentFetch() {
+	source := &blockingSource{release: make(chan struct{}), calls: make(chan struct{}, 10)}
+	c := s.client(source, accept("Bearer t1", http.StatusUnauthorized, ""))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.NoError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+		}()
+	}
+	<-source.calls
+
+	// A caller stops waiting for the token when its Context is done, without cancelling the fetch.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Equal(context.Canceled, c.Do(gql.NewRequest(`{ viewer { name } }`, gql.WithContext(ctx)), nil))
+
+	close(source.release)
+	wg.Wait()
+	s.Len(source.calls, 0)
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 5)
+}
+
+func (s *SuiteAuth) TestTokenErrors() {
+	source := s.source()
+	source.ClientSecret = "wrong"
+	c := s.client(source, accept("Bearer t1", http.StatusUnauthorized, ""))
+	s.EqualError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil), "token: token endpoint: invalid_client: unknown client")
+	s.httpClient.AssertNotCalled(s.T(), "Do", mock.Anything)
+}
@@ -0,0 +1,262 @@
+// Package builder builds GraphQL operations for gqlclient using method chaining, as an alternative
+// to concatenating query strings. Names are checked and argument values are converted into GraphQL
+// values, so that values cannot change the structure of the operation.
+//
+// Build an operation and make a Request for it
+//  op := builder.Query("GetUser").Var("id", "ID!")
+//  op.Field("user", builder.Arg("id", builder.Var("id"))).Select("name", "email")
+//  req, err := op.Request(gqlclient.WithVar("id", id))
+//
+// Fields can be nested, aliased and have directives, and inline fragments select fields for a type
+//  user := op.Field("user", builder.Arg("id", builder.Var("id")))
+//  user.Field("friends", builder.Arg("first", 10)).Alias("firstFriends").Select("name")
+//  user.On("Admin").Select("permissions")
+package builder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+
+	gql "github.com/weavedev/go-gqlclient"
+)
+
+var namePattern = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// Operation is a GraphQL operation that is being built. The first error that occurs while building,
+// like an invalid name or value, is returned by Document, Build and Request.
+type Operation struct {
+	op   *ast.OperationDefinition
+	used map[string]bool
+	err  error
+}
+
+// Query starts building a query with the given name. Use an empty name for an anonymous query.
+func Query(name string) *Operation {
+	return newOperation(ast.Query, name)
+}
+
+// Mutation starts building a mutation with the given name. Use an empty name for an anonymous
+// mutation.
+func Mutation(name string) *Operation {
+	return newOperation(ast.Mutation, name)
+}
+
+func newOperation(operation ast.Operation, name string) *Operation {
+	o := &Operation{
+		op:   &ast.OperationDefinition{Operation: operation, Name: name},
+		used: make(map[string]bool),
+	}
+	if name != "" {
+		o.checkName("operation", name)
+	}
+	return o
+}
+
+// setErr keeps the first error that occurs while building.
+func (o *Operation) setErr(err error) {
+	if o.err == nil {
+		o.err = err
+	}
+}
+
+// checkName checks that the name is a valid GraphQL name.
+func (o *Operation) checkName(kind, name string) bool {
+	if !namePattern.MatchString(name) {
+		o.setErr(fmt.Errorf("invalid %s name %q", kind, name))
+		return false
+	}
+	return true
+}
+
+// Var defines a variable of the operation with a GraphQL type, like "ID!" or "[String!]".
+func (o *Operation) Var(name, typ string) *Operation {
+	o.checkName("variable", name)
+	t, err := parseType(typ)
+	if err != nil {
+		o.setErr(fmt.Errorf("variable $%s: %w", name, err))
+		return o
+	}
+	if o.op.VariableDefinitions.ForName(name) != nil {
+		o.setErr(fmt.Errorf("variable $%s is defined twice", name))
+		return o
+	}
+	o.op.VariableDefinitions = append(o.op.VariableDefinitions, &ast.VariableDefinition{Variable: name, Type: t})
+	return o
+}
+
+// Field adds a field with arguments to the selection set of the operation, and returns it.
+func (o *Operation) Field(name string, args ...Argument) *Field {
+	return addField(o, &o.op.SelectionSet, name, args)
+}
+
+// Select adds fields without arguments to the selection set of the operation.
+func (o *Operation) Select(names ...string) *Operation {
+	for _, name := range names {
+		addField(o, &o.op.SelectionSet, name, nil)
+	}
+	return o
+}
+
+// Document returns the document with the operation.
+func (o *Operation) Document() (*ast.QueryDocument, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	if len(o.op.SelectionSet) == 0 {
+		return nil, errors.New("operation has no selections")
+	}
+	for name := range o.used {
+		if o.op.VariableDefinitions.ForName(name) == nil {
+			return nil, fmt.Errorf("variable $%s is not defined", name)
+		}
+	}
+	for _, def := range o.op.VariableDefinitions {
+		if !o.used[def.Variable] {
+			return nil, fmt.Errorf("variable $%s is not used", def.Variable)
+		}
+	}
+	return &ast.QueryDocument{Operations: ast.OperationList{o.op}}, nil
+}
+
+// Build returns the text of the operation.
+func (o *Operation) Build() (string, error) {
+	doc, err := o.Document()
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatQueryDocument(doc)
+	return buf.String(), nil
+}
+
+// Request makes a new Request for the operation. The options are passed to gqlclient.NewRequest.
+//  req, err := op.Request(gqlclient.WithVar("id", id))
+func (o *Operation) Request(opts ...gql.RequestOption) (*gql.Request, error) {
+	query, err := o.Build()
+	if err != nil {
+		return nil, err
+	}
+	return gql.NewRequest(query, opts...), nil
+}
+
+// Field is a field in the operation that is being built.
+type Field struct {
+	operation *Operation
+	field     *ast.Field
+}
+
+func addField(o *Operation, set *ast.SelectionSet, name string, args []Argument) *Field {
+	field := &ast.Field{Name: name, Alias: name}
+	if o.checkName("field", name) {
+		field.Arguments = o.arguments(args)
+	}
+	*set = append(*set, field)
+	return &Field{operation: o, field: field}
+}
+
+// Field adds a field with arguments to the selection set of the field, and returns it.
+func (f *Field) Field(name string, args ...Argument) *Field {
+	return addField(f.operation, &f.field.SelectionSet, name, args)
+}
+
+// Select adds fields without arguments to the selection set of the field.
+func (f *Field) Select(names ...string) *Field {
+	for _, name := range names {
+		addField(f.operation, &f.field.SelectionSet, name, nil)
+	}
+	return f
+}
+
+// Alias sets the alias of the field, which is the name of the field in the response.
+func (f *Field) Alias(alias string) *Field {
+	if f.operation.checkName("alias", alias) {
+		f.field.Alias = alias
+	}
+	return f
+}
+
+// Directive adds a directive with arguments to the field.
+func (f *Field) Directive(name string, args ...Argument) *Field {
+	f.field.Directives = append(f.field.Directives, f.operation.directive(name, args))
+	return f
+}
+
+// On adds an inline fragment for a type to the selection set of the field, and returns it.
+func (f *Field) On(typeCondition string) *InlineFragment {
+	return addInlineFragment(f.operation, &f.field.SelectionSet, typeCondition)
+}
+
+// Operation returns the operation that the field is part of.
+func (f *Field) Operation() *Operation {
+	return f.operation
+}
+
+// InlineFragment is an inline fragment in the operation that is being built.
+type InlineFragment struct {
+	operation *Operation
+	fragment  *ast.InlineFragment
+}
+
+func addInlineFragment(o *Operation, set *ast.SelectionSet, typeCondition string) *InlineFragment {
+	o.checkName("type", typeCondition)
+	fragment := &ast.InlineFragment{TypeCondition: typeCondition}
+	*set = append(*set, fragment)
+	return &InlineFragment{operation: o, fragment: fragment}
+}
+
+// Field adds a field with arguments to the selection set of the fragment, and returns it.
+func (i *InlineFragment) Field(name string, args ...Argument) *Field {
+	return addField(i.operation, &i.fragment.SelectionSet, name, args)
+}
+
+// Select adds fields without arguments to the selection set of the fragment.
+func (i *InlineFragment) Select(names ...string) *InlineFragment {
+	for _, name := range names {
+		addField(i.operation, &i.fragment.SelectionSet, name, nil)
+	}
+	return i
+}
+
+// Directive adds a directive with arguments to the fragment.
+func (i *InlineFragment) Directive(name string, args ...Argument) *InlineFragment {
+	i.fragment.Directives = append(i.fragment.Directives, i.operation.directive(name, args))
+	return i
+}
+
+// Operation returns the operation that the fragment is part of.
+func (i *InlineFragment) Operation() *Operation {
+	return i.operation
+}
+
+func (o *Operation) directive(name string, args []Argument) *ast.Directive {
+	dir := &ast.Directive{Name: name}
+	if o.checkName("directive", name) {
+		dir.Arguments = o.arguments(args)
+	}
+	return dir
+}
+
+// parseType parses a GraphQL type reference like "ID!" or "[String!]".
+func parseType(typ string) (*ast.Type, error) {
+	s := strings.TrimSpace(typ)
+	nonNull := strings.HasSuffix(s, "!")
+	s = strings.TrimSpace(strings.TrimSuffix(s, "!"))
+
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		elem, err := parseType(s[1 : len(s)-1])
+		if err != nil {
+			return nil, err
+		}
+		return &ast.Type{Elem: elem, NonNull: nonNull}, nil
+	}
+	if !namePattern.MatchString(s) {
+		return nil, fmt.Errorf("invalid type %q", typ)
+	}
+	return &ast.Type{NamedType: s, NonNull: nonNull}, nil
+}
@@ -0,0 +1,146 @@
+package builder_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/builder"
+)
+
+var schema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	enum Role { ADMIN USER }
+	input Filter { name: String, roles: [Role!] }
+	type Query {
+		user(id: ID!): User
+		users(filter: Filter, first: Int): [User!]!
+	}
+	type Mutation {
+		rename(id: ID!, name: String!): User!
+	}
+	interface Node { id: ID! }
+	type User implements Node {
+		id: ID!
+		name: String!
+		email: String
+		friends(first: Int): [User!]!
+	}
+	type Admin implements Node {
+		id: ID!
+		permissions: [String!]!
+	}
+`})
+
+type SuiteBuilder struct {
+	suite.Suite
+}
+
+func TestSuiteBuilder(t *testing.T) {
+	s := SuiteBuilder{}
+	suite.Run(t, &s)
+}
+
+// assertValid checks that the operation is valid for the schema, and returns the parsed document.
+func (s *SuiteBuilder) assertValid(op *builder.Operation) *ast.QueryDocument {
+	query, err := op.Build()
+	s.Require().NoError(err)
+	doc, errs := gqlparser.LoadQuery(schema, query)
+	s.Require().Empty(errs, query)
+	return doc
+}
+
+func (s *SuiteBuilder) TestQuery() {
+	op := builder.Query("GetUser").Var("id", "ID!")
+	op.Field("user", builder.Arg("id", builder.Var("id"))).Select("name", "email")
+
+	query, err := op.Build()
+	s.Require().NoError(err)
+	s.Equal("query GetUser ($id: ID!) {\n\tuser(id: $id) {\n\t\tname\n\t\temail\n\t}\n}\n", query)
+	s.assertValid(op)
+
+	req, err := op.Request(gql.WithVar("id", "1"))
+	s.Require().NoError(err)
+	s.Equal(query, req.Query)
+	s.Equal(map[string]interface{}{"id": "1"}, req.Variables)
+}
+
+func (s *SuiteBuilder) TestNested() {
+	op := builder.Query("")
+	users := op.Field("users",
+		builder.Arg("filter", map[string]interface{}{
+			"name":  "Bob",
+			"roles": []builder.EnumValue{builder.Enum("ADMIN")},
+		}),
+		builder.Arg("first", 10),
+	)
+	users.Select("id")
+	users.Field("friends", builder.Arg("first", 2)).Alias("bestFriends").
+		Directive("include", builder.Arg("if", true)).
+		Select("name")
+
+	query, err := op.Build()
+	s.Require().NoError(err)
+	s.Equal("query {\n\tusers(filter: {name:\"Bob\",roles:[ADMIN]}, first: 10) {\n\t\tid\n"+
+		"\t\tbestFriends: friends(first: 2) @include(if: true) {\n\t\t\tname\n\t\t}\n\t}\n}\n", query)
+	s.assertValid(op)
+}
+
+func (s *SuiteBuilder) TestInlineFragment() {
+	op := builder.Query("Node").Var("id", "ID!")
+	user := op.Field("user", builder.Arg("id", builder.Var("id")))
+	user.Select("id")
+	user.On("User").Select("name")
+	s.assertValid(op)
+}
+
+func (s *SuiteBuilder) TestMutation() {
+	op := builder.Mutation("Rename").Var("id", "ID!").Var("name", "String!")
+	op.Field("rename", builder.Arg("id", builder.Var("id")), builder.Arg("name", builder.Var("name"))).
+		Select("id")
+	s.assertValid(op)
+}
+
+func (s *SuiteBuilder) TestStringValues() {
+	// Values cannot change the structure of the operation.
+	name := `Bob") { id } admin: user(id: "1`
+	op := builder.Query("")
+	op.Field("users", builder.Arg("filter", map[string]interface{}{"name": name})).Select("id")
+
+	doc := s.assertValid(op)
+	s.Require().Len(doc.Operations[0].SelectionSet, 1)
+	field := doc.Operations[0].SelectionSet[0].(*ast.Field)
+	s.Equal(name, field.Arguments[0].Value.Children[0].Value.Raw)
+
+	op = builder.Query("")
+	op.Field("users", builder.Arg("filter", map[string]interface{}{"name": "line\nbreak "})).Select("id")
+	doc = s.assertValid(op)
+	field = doc.Operations[0].SelectionSet[0].(*ast.Field)
+	s.Equal("line\nbreak ", field.Arguments[0].Value.Children[0].Value.Raw)
+
+	op = builder.Query("")
+	op.Field("users", builder.Arg("filter", map[string]interface{}{"name": "bell\a"})).Select("id")
+	_, err := op.Build()
+	s.EqualError(err, `argument filter: string "bell\a" cannot be written in a document, use a variable`)
+}
+
+func (s *SuiteBuilder) TestErrors() {
+	tests := map[string]*builder.Operation{
+		`invalid field name "user { id }"`:  builder.Query("").Select("user { id }"),
+		`invalid operation name "Get User"`: builder.Query("Get User").Select("id"),
+		`variable $id: invalid type "ID!)"`: builder.Query("").Var("id", "ID!)").Select("id"),
+		`variable $id is not defined`:       builder.Query("").Field("user", builder.Arg("id", builder.Var("id"))).Operation(),
+		`variable $id is not used`:          builder.Query("").Var("id", "ID!").Select("users"),
+		`operation has no selections`:       builder.Query(""),
+		`argument id: unsupported value of type struct {}`: builder.Query("").
+			Field("user", builder.Arg("id", struct{}{})).Operation(),
+		`argument role: invalid enum value "true"`: builder.Query("").
+			Field("user", builder.Arg("role", builder.Enum("true"))).Operation(),
+	}
+	for expected, op := range tests {
+		_, err := op.Request()
+		s.EqualError(err, expected)
+	}
+}
@@ -0,0 +1,159 @@
+package builder
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Argument is an argument of a field or directive.
+type Argument struct {
+	name  string
+	value interface{}
+}
+
+// Arg returns an argument with a value. The value can be a Variable, an EnumValue, nil, a string, a
+// boolean, a number, or a pointer, slice, array or map with string keys of those.
+//  builder.Arg("first", 10)
+func Arg(name string, value interface{}) Argument {
+	return Argument{name: name, value: value}
+}
+
+// Variable is a reference to a variable of the operation, used as a value.
+type Variable string
+
+// Var returns a reference to a variable of the operation.
+//  builder.Arg("id", builder.Var("id"))
+func Var(name string) Variable {
+	return Variable(name)
+}
+
+// EnumValue is an enum value, which is written without quotes.
+type EnumValue string
+
+// Enum returns an enum value.
+//  builder.Arg("role", builder.Enum("ADMIN"))
+func Enum(value string) EnumValue {
+	return EnumValue(value)
+}
+
+func (o *Operation) arguments(args []Argument) ast.ArgumentList {
+	list := make(ast.ArgumentList, 0, len(args))
+	for _, arg := range args {
+		if !o.checkName("argument", arg.name) {
+			continue
+		}
+		value, err := o.value(arg.value)
+		if err != nil {
+			o.setErr(fmt.Errorf("argument %s: %w", arg.name, err))
+			continue
+		}
+		list = append(list, &ast.Argument{Name: arg.name, Value: value})
+	}
+	return list
+}
+
+// value converts a Go value into a GraphQL value.
+func (o *Operation) value(v interface{}) (*ast.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return &ast.Value{Kind: ast.NullValue, Raw: "null"}, nil
+	case Variable:
+		if !namePattern.MatchString(string(v)) {
+			return nil, fmt.Errorf("invalid variable name %q", string(v))
+		}
+		o.used[string(v)] = true
+		return &ast.Value{Kind: ast.Variable, Raw: string(v)}, nil
+	case EnumValue:
+		if !namePattern.MatchString(string(v)) || v == "true" || v == "false" || v == "null" {
+			return nil, fmt.Errorf("invalid enum value %q", string(v))
+		}
+		return &ast.Value{Kind: ast.EnumValue, Raw: string(v)}, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		if !representable(rv.String()) {
+			return nil, fmt.Errorf("string %q cannot be written in a document, use a variable", rv.String())
+		}
+		return &ast.Value{Kind: ast.StringValue, Raw: rv.String()}, nil
+	case reflect.Bool:
+		return &ast.Value{Kind: ast.BooleanValue, Raw: strconv.FormatBool(rv.Bool())}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &ast.Value{Kind: ast.IntValue, Raw: strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &ast.Value{Kind: ast.IntValue, Raw: strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%v is not a valid Float", f)
+		}
+		return &ast.Value{Kind: ast.FloatValue, Raw: strconv.FormatFloat(f, 'g', -1, 64)}, nil
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return o.value(nil)
+		}
+		return o.value(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return o.value(nil)
+		}
+		list := &ast.Value{Kind: ast.ListValue}
+		for i := 0; i < rv.Len(); i++ {
+			elem, err := o.value(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			list.Children = append(list.Children, &ast.ChildValue{Value: elem})
+		}
+		return list, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported value of type %T", v)
+		}
+		if rv.IsNil() {
+			return o.value(nil)
+		}
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+		object := &ast.Value{Kind: ast.ObjectValue}
+		for _, key := range keys {
+			if !namePattern.MatchString(key.String()) {
+				return nil, fmt.Errorf("invalid field name %q", key.String())
+			}
+			field, err := o.value(rv.MapIndex(key).Interface())
+			if err != nil {
+				return nil, err
+			}
+			object.Children = append(object.Children, &ast.ChildValue{Name: key.String(), Value: field})
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %T", v)
+}
+
+// representable reports whether a string is written as a valid GraphQL string. The formatter
+// quotes strings like Go does, which only matches GraphQL for valid UTF-8 where the escaped
+// characters are common whitespace or can be escaped using \uXXXX.
+func representable(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r == '\b' || r == '\f' || r == '\n' || r == '\r' || r == '\t' || strconv.IsPrint(r):
+		case r < utf8.RuneSelf || r > 0xFFFF:
+			// Written as \xXX, \a, \v or \UXXXXXXXX.
+			return false
+		}
+	}
+	return true
+}
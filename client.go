@@ -1,9 +1,10 @@
 package gqlclient
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 type HTTPClient interface {
@@ -16,8 +17,24 @@ type RequestBuilder func(endpoint string, req *Request) (*http.Request, error)
 type Client struct {
 	endpoint       string
 	httpClient     HTTPClient
-	defaultHeaders map[string]string
+	defaultHeaders http.Header
+	headerFuncs    []HeaderFunc
 	requestBuilder RequestBuilder
+	codec          Codec
+	decodeOptions  DecodeOptions
+	encodings      encodings
+	compression    *requestCompression
+	validator      *queryValidator
+	scalarEncoders map[string]ScalarEncoder
+	shapes         *shapeChecker
+	normalize      bool
+	queries        *queryCache
+	trusted        *trustedDocuments
+	cache          *NormalizedCache
+	responseCache  *responseCache
+	inflight       *inflightRequests
+	tokens         *tokenAuth
+	signer         Signer
 }
 
 // NewClient makes a new Client capable of making GraphQL requests.
@@ -25,12 +42,18 @@ func NewClient(endpoint string, opts ...ClientOption) *Client {
 	client := &Client{
 		endpoint:       endpoint,
 		httpClient:     http.DefaultClient,
-		defaultHeaders: make(map[string]string),
+		defaultHeaders: make(http.Header),
 		requestBuilder: JSONRequestBuilder,
+		codec:          JSONCodec,
+		queries:        newQueryCache(defaultQueryCacheSize),
 	}
 
 	// Set default Accept header
-	client.defaultHeaders["Accept"] = "application/json; charset=utf-8"
+	client.defaultHeaders.Set("Accept", "application/json; charset=utf-8")
+
+	// Negotiate the compression of responses.
+	client.encodings.set("gzip", GzipEncoding)
+	client.encodings.set("deflate", DeflateEncoding)
 
 	// Parse options
 	for _, optionFunc := range opts {
@@ -44,26 +67,44 @@ func NewClient(endpoint string, opts ...ClientOption) *Client {
 // object. Pass in a nil response object to skip response parsing. If the request fails or the
 // server returns an error, the first error will be returned.
 func (c *Client) Do(req *Request, resp interface{}) (err error) {
-	httpReq, err := c.requestBuilder(c.endpoint, req)
-	if err != nil {
-		return fmt.Errorf("request builder: %w", err)
+	// Check that the response object can hold the selected fields.
+	if c.shapes != nil && resp != nil {
+		if c.validator == nil {
+			return errShapeCheckSchema
+		}
+		q := c.queries.get(req.Query)
+		doc, err := c.validator.validate(q)
+		if err != nil {
+			return err
+		}
+		if err := c.shapes.check(q, doc, resp); err != nil {
+			return err
+		}
 	}
-	httpReq = httpReq.WithContext(req.ctx)
 
-	// Set default headers.
-	for key, value := range c.defaultHeaders {
-		httpReq.Header.Set(key, value)
+	// Use the normalized cache for queries and mutations.
+	if c.cache != nil {
+		var schema *ast.Schema
+		if c.validator != nil {
+			schema = c.validator.schema
+		}
+		if op := c.cache.operation(req, c.queries.get(req.Query), schema); op != nil {
+			return c.doCached(req, op, resp)
+		}
 	}
 
-	// Set request headers.
-	for key, value := range req.headers {
-		httpReq.Header.Set(key, value)
+	// Share the response with identical concurrent queries.
+	if c.inflight != nil {
+		raw, err := c.doRaw(req)
+		if err != nil {
+			return err
+		}
+		return c.decodeRaw(req, raw, resp)
 	}
 
-	// Do the request.
-	httpResp, err := c.httpClient.Do(httpReq)
+	httpResp, err := c.send(req, c.header(req), false)
 	if err != nil {
-		return fmt.Errorf("do request: %w", err)
+		return err
 	}
 	defer func() {
 		cerr := httpResp.Body.Close()
@@ -73,27 +114,230 @@ func (c *Client) Do(req *Request, resp interface{}) (err error) {
 	}()
 
 	// Decode the response body.
+	decodeOptions := c.decodeOptionsFor(req)
 	var gqlResp responseWithErrors
-	if resp == nil {
+	var rawResp *rawDataResponse
+	switch {
+	case resp == nil:
 		// Skip data decoding if there is nothing to decode into. Only decode errors if they exist.
 		gqlResp = &errorsResponse{}
-	} else {
+	case decodeOptions.DisallowUnknownFields || decodeOptions.Strict:
+		// Decode the data in a separate step, so the options only apply to the data.
+		rawResp = &rawDataResponse{}
+		gqlResp = rawResp
+	default:
 		gqlResp = &response{Data: resp}
 	}
-	if err := json.NewDecoder(httpResp.Body).Decode(gqlResp); err != nil {
-		// GraphQL endpoints should always return a 200, as per GraphQL spec. So, if there was was a
-		// problem decoding the response, something outside of the GraphQL layer went wrong.
-		if httpResp.StatusCode != http.StatusOK {
-			return NewHTTPError(httpResp.StatusCode)
-		}
-		return ErrBadResponse
+	dec, err := decodeOptions.newDecoder(c.codec, httpResp.Body, false)
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(gqlResp); err != nil {
+		return badResponseError(httpResp)
+	}
+	var dataErr error
+	if rawResp != nil && len(rawResp.Data) > 0 {
+		dataErr = decodeOptions.decodeData(c.codec, rawResp.Data, resp)
 	}
 
 	// Return the GraphQL errors, if any.
 	if len(gqlResp.getErrors()) > 0 {
 		return gqlResp.getErrors()
 	}
-	return nil
+	return dataErr
+}
+
+// DoRaw executes the Request and returns the response without decoding the data, errors and
+// extensions fields. GraphQL errors are not returned as an error, so the response can be forwarded
+// as is.
+func (c *Client) DoRaw(req *Request) (*RawResponse, error) {
+	return c.sendRaw(req, c.header(req))
+}
+
+// sendRaw sends the Request with the headers, and returns the response like DoRaw.
+func (c *Client) sendRaw(req *Request, header requestHeader) (raw *RawResponse, err error) {
+	httpResp, err := c.send(req, header, false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		cerr := httpResp.Body.Close()
+		if cerr != nil && err == nil {
+			raw, err = nil, fmt.Errorf("close body: %w", cerr)
+		}
+	}()
+
+	raw = &RawResponse{
+		StatusCode: httpResp.StatusCode,
+		Header:     httpResp.Header,
+	}
+	if err := c.codec.NewDecoder(httpResp.Body).Decode(raw); err != nil {
+		return nil, badResponseError(httpResp)
+	}
+	return raw, nil
+}
+
+// send builds the http request for the Request and sends it using the HTTPClient. The body of a
+// streamed response is not read in memory.
+func (c *Client) send(req *Request, header requestHeader, streamed bool) (*http.Response, error) {
+	// Build the http request from a copy of the Request that uses the Codec of the Client.
+	buildReq := *req
+	buildReq.codec = c.codec
+	buildReq.compression = c.compression
+
+	// Validate the query and variables against the schema. The query as written is validated, so
+	// the locations of errors point into it.
+	q := c.queries.get(req.Query)
+	if c.validator != nil {
+		doc, err := c.validator.validate(q)
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Operations) == 1 {
+			coercer := &variableCoercer{schema: c.validator.schema, encoders: c.scalarEncoders, codec: c.codec}
+			if buildReq.Variables, err = coercer.coerceVariables(doc.Operations[0], req.Variables); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Send the normalized query.
+	if c.normalize {
+		query, err := q.normalize()
+		if err != nil {
+			return nil, err
+		}
+		buildReq.Query = query
+	}
+
+	// Send the id of the trusted document instead of the query.
+	if c.trusted != nil {
+		if err := c.trusted.apply(&buildReq, q); err != nil {
+			return nil, err
+		}
+	}
+
+	// Answer queries from the response cache, before a token is fetched and the request is signed.
+	var cacheKey string
+	if c.responseCache != nil {
+		variables, err := c.codec.Marshal(buildReq.Variables)
+		if err != nil {
+			return nil, fmt.Errorf("encode variables: %w", err)
+		}
+		var ok bool
+		if cacheKey, ok = c.responseCache.key(c.endpoint, q, variables, header); ok {
+			if httpResp, ok := c.responseCache.get(cacheKey, header); ok {
+				return httpResp, nil
+			}
+		}
+	}
+
+	httpReq, token, err := c.newHTTPRequest(req, &buildReq, header, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Do the request.
+	httpResp, err := c.do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request once more with a new token when the token was rejected.
+	if token != nil {
+		rejected, err := c.tokens.rejected(httpResp, c.codec, !streamed)
+		if err != nil {
+			return nil, err
+		}
+		if rejected {
+			if httpReq, _, err = c.newHTTPRequest(req, &buildReq, header, token); err != nil {
+				return nil, err
+			}
+			if httpResp, err = c.do(httpReq); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Store the response body in the response cache, unless it is streamed and is not read in memory.
+	if cacheKey != "" && !streamed {
+		if err := c.responseCache.set(cacheKey, header, httpResp, c.codec); err != nil {
+			return nil, err
+		}
+	}
+	return httpResp, nil
+}
+
+// newHTTPRequest builds the http request for the Request, sets its headers and signs it. The token that is
+// sent in the Authorization header is returned, which replaces the rejected token if any.
+func (c *Client) newHTTPRequest(req *Request, buildReq *Request, header requestHeader, rejected *Token) (*http.Request, *Token, error) {
+	httpReq, err := c.requestBuilder(c.endpoint, buildReq)
+	if err != nil {
+		return nil, nil, fmt.Errorf("request builder: %w", err)
+	}
+	httpReq = httpReq.WithContext(req.ctx)
+
+	// Advertise the registered content codings.
+	if len(c.encodings.names) > 0 {
+		httpReq.Header.Set("Accept-Encoding", c.encodings.acceptEncoding())
+	}
+
+	// Set default headers.
+	setHeader(httpReq.Header, header.defaults)
+
+	// Set the token, unless the Request has its own Authorization header.
+	var token *Token
+	if c.tokens != nil && header.request.Get("Authorization") == "" {
+		if token, err = c.tokens.get(req.ctx, rejected); err != nil {
+			return nil, nil, err
+		}
+		httpReq.Header.Set("Authorization", token.authorization())
+	}
+
+	// Set request headers.
+	setHeader(httpReq.Header, header.request)
+
+	// Sign the complete http request.
+	if c.signer != nil {
+		if err := sign(c.signer, httpReq); err != nil {
+			return nil, nil, err
+		}
+	}
+	return httpReq, token, nil
+}
+
+// do sends the http request using the HTTPClient, and decompresses the response body.
+func (c *Client) do(httpReq *http.Request) (*http.Response, error) {
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+
+	// Decompress the response body.
+	if len(c.encodings.names) > 0 {
+		if err := c.encodings.decompress(httpResp); err != nil {
+			return nil, err
+		}
+	}
+	return httpResp, nil
+}
+
+// decodeOptionsFor returns the DecodeOptions for the response of the Request.
+func (c *Client) decodeOptionsFor(req *Request) DecodeOptions {
+	if req.decode != nil {
+		return *req.decode
+	}
+	return c.decodeOptions
+}
+
+// badResponseError returns the error for a response body that could not be decoded.
+func badResponseError(httpResp *http.Response) error {
+	// GraphQL endpoints should always return a 200, as per GraphQL spec. So, if there was was a
+	// problem decoding the response, something outside of the GraphQL layer went wrong.
+	if httpResp.StatusCode != http.StatusOK {
+		return NewHTTPError(httpResp.StatusCode)
+	}
+	return ErrBadResponse
 }
 
 // ClientOption are functions that are passed into NewClient to modify the behaviour of the Client.
@@ -111,7 +355,7 @@ func WithHTTPClient(httpclient HTTPClient) ClientOption {
 //  NewClient(endpoint, WithDefaultHeader(key, value))
 func WithDefaultHeader(key string, value string) ClientOption {
 	return func(client *Client) {
-		client.defaultHeaders[key] = value
+		client.defaultHeaders.Set(key, value)
 	}
 }
 
@@ -122,3 +366,20 @@ func WithRequestBuilder(builder RequestBuilder) ClientOption {
 		client.requestBuilder = builder
 	}
 }
+
+// WithCodec sets the Codec that is used to encode requests and decode responses.
+//  NewClient(endpoint, WithCodec(codec))
+func WithCodec(codec Codec) ClientOption {
+	return func(client *Client) {
+		client.codec = codec
+	}
+}
+
+// WithDefaultDecodeOptions sets the DecodeOptions that are used to decode the response of every
+// Request sent with this client.
+//  NewClient(endpoint, WithDefaultDecodeOptions(DecodeOptions{Strict: true}))
+func WithDefaultDecodeOptions(opts DecodeOptions) ClientOption {
+	return func(client *Client) {
+		client.decodeOptions = opts
+	}
+}
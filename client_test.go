@@ -2,6 +2,7 @@ package gqlclient_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -265,3 +266,76 @@ func (s *SuiteClient) TestGQLError() {
 	s.Assert().Len(gqlerrs, 1)
 	s.Equal("invalid query", gqlerrs[0].Message)
 }
+
+func (s *SuiteClient) TestWithCodec() {
+	var resp struct {
+		Value string
+	}
+
+	codec := new(mocks.Codec)
+	codec.
+		On("Marshal", mock.AnythingOfType("*gqlclient.Request")).
+		Return([]byte(`{"query":""}`), nil)
+	codec.
+		On("NewDecoder", mock.Anything).
+		Return(json.NewDecoder(strings.NewReader(`{"data": {"value": "decoded"}}`)))
+
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			StatusCode: http.StatusOK,
+		}, nil)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient), gql.WithCodec(codec))
+	err := c.Do(gql.NewRequest(""), &resp)
+	codec.AssertExpectations(s.T())
+	httpClient.AssertExpectations(s.T())
+	s.NoError(err)
+	s.Equal("decoded", resp.Value)
+}
+
+func (s *SuiteClient) TestDoRaw() {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Body: ioutil.NopCloser(strings.NewReader(`{
+				"data": {"value": "some data"},
+				"errors": [{"message": "partial failure"}],
+				"extensions": {"cost": 3}
+			}`)),
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"X-Test": []string{"value"}},
+		}, nil)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient))
+	raw, err := c.DoRaw(gql.NewRequest(""))
+	httpClient.AssertExpectations(s.T())
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, raw.StatusCode)
+	s.Equal("value", raw.Header.Get("X-Test"))
+	s.JSONEq(`{"value": "some data"}`, string(raw.Data))
+	s.JSONEq(`[{"message": "partial failure"}]`, string(raw.Errors))
+	s.JSONEq(`{"cost": 3}`, string(raw.Extensions))
+}
+
+func (s *SuiteClient) TestDoRawHTTPError() {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			StatusCode: http.StatusBadGateway,
+			Body:       ioutil.NopCloser(strings.NewReader("<html></html>")),
+		}, nil)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient))
+	raw, err := c.DoRaw(gql.NewRequest(""))
+
+	httpClient.AssertExpectations(s.T())
+	s.Nil(raw)
+	var herr *gql.HTTPError
+	s.Require().ErrorAs(err, &herr)
+	s.Equal(http.StatusBadGateway, herr.StatusCode)
+}
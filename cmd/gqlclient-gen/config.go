@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of gqlclient-gen, which is read from a YAML file. Relative paths are
+// relative to the directory of the config file.
+type Config struct {
+	// Schema is the path to an SDL file, a directory with SDL files, or a .json file with the result
+	// of an introspection query.
+	Schema string `yaml:"schema"`
+	// Operations is the path to the directory with the .graphql operation files.
+	Operations string `yaml:"operations"`
+	// Output is the path of the generated Go file.
+	Output string `yaml:"output"`
+	// Package is the package name of the generated Go file.
+	Package string `yaml:"package"`
+	// Scalars maps GraphQL scalar types to Go types, for example "time.Time" or
+	// "github.com/google/uuid.UUID". Custom scalars that are not mapped use json.RawMessage.
+	// Nullable scalars are pointers, except for json.RawMessage and the other named slice and map
+	// types of the standard library, like net.IP.
+	Scalars map[string]string `yaml:"scalars"`
+}
+
+// loadConfig reads the config file and resolves the paths in it.
+func loadConfig(path string) (*Config, error) {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(input))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("decode config %s: %w", path, err)
+	}
+
+	switch {
+	case cfg.Schema == "":
+		return nil, errors.New("config: schema is required")
+	case cfg.Operations == "":
+		return nil, errors.New("config: operations is required")
+	case cfg.Package == "":
+		return nil, errors.New("config: package is required")
+	}
+	if cfg.Output == "" {
+		cfg.Output = "generated.go"
+	}
+
+	dir := filepath.Dir(path)
+	for _, p := range []*string{&cfg.Schema, &cfg.Operations, &cfg.Output} {
+		if !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+// gqlclientPath is the import path of the gqlclient package that the generated code uses.
+const gqlclientPath = "github.com/weavedev/go-gqlclient"
+
+// builtinScalars maps the built-in scalar types to Go types.
+var builtinScalars = map[string]goType{
+	"Int":     {expr: "int"},
+	"Float":   {expr: "float64"},
+	"String":  {expr: "string"},
+	"Boolean": {expr: "bool"},
+	"ID":      {expr: "string"},
+}
+
+// rawMessage is the Go type of custom scalars that are not mapped.
+var rawMessage = goType{importPath: "encoding/json", expr: "json.RawMessage", nillable: true}
+
+// nillableTypes are the named slice and map types of the standard library, which hold a null
+// without a pointer.
+var nillableTypes = map[string]bool{
+	"encoding/json.RawMessage": true,
+	"net.IP":                   true,
+	"net.IPMask":               true,
+	"net/http.Header":          true,
+	"net/url.Values":           true,
+}
+
+// goType is a Go type that a scalar type is mapped to.
+type goType struct {
+	// importPath of the package of the type, if any.
+	importPath string
+	// expr is the type expression, qualified with the package name.
+	expr string
+	// nillable is set for named slice and map types, which are not made a pointer when nullable.
+	nillable bool
+}
+
+var versionSuffix = regexp.MustCompile(`^v[0-9]+$`)
+
+// parseGoType parses a scalar mapping like "time.Time", "*time.Time" or
+// "github.com/google/uuid.UUID".
+func parseGoType(mapping string) (goType, error) {
+	typeName := strings.TrimLeft(mapping, "*[]")
+	prefix := mapping[:len(mapping)-len(typeName)]
+	i := strings.LastIndex(typeName, ".")
+	if i < 0 {
+		return goType{expr: mapping}, nil
+	}
+	importPath, name := typeName[:i], typeName[i+1:]
+	if importPath == "" || name == "" {
+		return goType{}, fmt.Errorf("invalid Go type %q", mapping)
+	}
+
+	// The package name is the last element of the import path, skipping a major version suffix.
+	pkgName := path.Base(importPath)
+	if dir := path.Dir(importPath); versionSuffix.MatchString(pkgName) && dir != "." {
+		pkgName = path.Base(dir)
+	}
+	return goType{
+		importPath: importPath,
+		expr:       prefix + pkgName + "." + name,
+		nillable:   prefix == "" && nillableTypes[typeName],
+	}, nil
+}
+
+// generator generates the Go code for the operations in a document.
+type generator struct {
+	schema  *ast.Schema
+	doc     *ast.QueryDocument
+	scalars map[string]goType
+	// imports maps the import paths to their names, which are empty when not renamed.
+	imports map[string]string
+	// types are the enum and input object types that are used by the operations.
+	types map[string]*ast.Definition
+	// embedded are the fragments that are embedded in the generated struct types, in order of use.
+	embedded ast.FragmentDefinitionList
+	buf      bytes.Buffer
+}
+
+// generate returns the formatted Go code for the operations in the document.
+func generate(schema *ast.Schema, doc *ast.QueryDocument, cfg *Config) ([]byte, error) {
+	g := &generator{
+		schema:  schema,
+		doc:     doc,
+		scalars: make(map[string]goType),
+		imports: map[string]string{"context": "", gqlclientPath: "gqlclient"},
+		types:   make(map[string]*ast.Definition),
+	}
+	for name, t := range builtinScalars {
+		g.scalars[name] = t
+	}
+	for name, mapping := range cfg.Scalars {
+		t, err := parseGoType(mapping)
+		if err != nil {
+			return nil, fmt.Errorf("scalar %s: %w", name, err)
+		}
+		g.scalars[name] = t
+	}
+
+	// The __typename of interfaces and unions is needed to decode them into the right type.
+	for _, op := range doc.Operations {
+		g.addTypename(op.SelectionSet)
+	}
+	for _, frag := range doc.Fragments {
+		g.addTypename(frag.SelectionSet)
+	}
+
+	for _, op := range doc.Operations {
+		if err := g.operation(op); err != nil {
+			return nil, err
+		}
+	}
+	g.fragmentTypes()
+	g.schemaTypes()
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by gqlclient-gen. DO NOT EDIT.\n\npackage %s\n\n", cfg.Package)
+	g.writeImports(&out)
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// comment writes a description as a comment.
+func (g *generator) comment(indent, description string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(description), "\n") {
+		g.printf("%s// %s\n", indent, strings.TrimRight(line, " \t"))
+	}
+}
+
+// writeImports writes the imports, with the standard library packages in a separate group.
+func (g *generator) writeImports(out *bytes.Buffer) {
+	paths := make([]string, 0, len(g.imports))
+	for p := range g.imports {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		iStd, jStd := isStdPackage(paths[i]), isStdPackage(paths[j])
+		if iStd != jStd {
+			return iStd
+		}
+		return paths[i] < paths[j]
+	})
+
+	out.WriteString("import (\n")
+	for i, p := range paths {
+		if i > 0 && isStdPackage(paths[i-1]) && !isStdPackage(p) {
+			out.WriteString("\n")
+		}
+		if name := g.imports[p]; name != "" {
+			fmt.Fprintf(out, "\t%s %q\n", name, p)
+		} else {
+			fmt.Fprintf(out, "\t%q\n", p)
+		}
+	}
+	out.WriteString(")\n\n")
+}
+
+// isStdPackage reports whether the import path is of a standard library package.
+func isStdPackage(importPath string) bool {
+	return !strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".")
+}
+
+// operation generates the document, the function and the response types of an operation.
+func (g *generator) operation(op *ast.OperationDefinition) error {
+	if op.Name == "" {
+		return fmt.Errorf("%s: operations must be named", position(op.Position))
+	}
+	if op.Operation == ast.Subscription {
+		return fmt.Errorf("%s: subscription %s is not supported", position(op.Position), op.Name)
+	}
+	name := goName(op.Name)
+	description := fmt.Sprintf("%s %s", op.Name, op.Operation)
+
+	var doc bytes.Buffer
+	formatter.NewFormatter(&doc).FormatQueryDocument(&ast.QueryDocument{
+		Operations: ast.OperationList{op},
+		Fragments:  g.usedFragments(op.SelectionSet, nil),
+	})
+	g.printf("// %sDocument is the document of the %s.\n", name, description)
+	g.printf("const %sDocument = %s\n\n", name, quote(doc.String()))
+
+	params := []string{"ctx context.Context", "c *gqlclient.Client"}
+	var required, optional []*ast.VariableDefinition
+	for _, def := range op.VariableDefinitions {
+		params = append(params, paramName(def.Variable)+" "+g.inputType(def.Type))
+		if def.Type.NonNull {
+			required = append(required, def)
+		} else {
+			optional = append(optional, def)
+		}
+	}
+
+	g.printf("// %s executes the %s.\n", name, description)
+	g.printf("// The errors of the response are returned together with the data of the response.\n")
+	if len(optional) > 0 {
+		g.printf("// Optional variables are not sent when they are nil.\n")
+	}
+	g.printf("func %s(%s) (*%sResponse, error) {\n", name, strings.Join(params, ", "), name)
+	g.printf("\topts := []gqlclient.RequestOption{\n\t\tgqlclient.WithContext(ctx),\n")
+	for _, def := range required {
+		g.printf("\t\tgqlclient.WithVar(%q, %s),\n", def.Variable, paramName(def.Variable))
+	}
+	g.printf("\t}\n")
+	for _, def := range optional {
+		param := paramName(def.Variable)
+		g.printf("\tif %s != nil {\n\t\topts = append(opts, gqlclient.WithVar(%q, %s))\n\t}\n", param, def.Variable, param)
+	}
+	g.printf("\tvar resp %sResponse\n", name)
+	g.printf("\terr := c.Do(gqlclient.NewRequest(%sDocument, opts...), &resp)\n", name)
+	g.printf("\treturn &resp, err\n}\n\n")
+
+	root := g.schema.Query
+	if op.Operation == ast.Mutation {
+		root = g.schema.Mutation
+	}
+	g.structType(name+"Response", "the response of the "+description, name, op.SelectionSet, root, nil)
+	return nil
+}
+
+// usedFragments returns the fragments that are used by the selection set, including the fragments
+// that are used by those fragments.
+func (g *generator) usedFragments(selections ast.SelectionSet, list ast.FragmentDefinitionList) ast.FragmentDefinitionList {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			list = g.usedFragments(selection.SelectionSet, list)
+		case *ast.InlineFragment:
+			list = g.usedFragments(selection.SelectionSet, list)
+		case *ast.FragmentSpread:
+			if list.ForName(selection.Name) != nil {
+				continue
+			}
+			def := g.doc.Fragments.ForName(selection.Name)
+			list = append(list, def)
+			list = g.usedFragments(def.SelectionSet, list)
+		}
+	}
+	return list
+}
+
+// inputType returns the Go type for a variable or a field of an input object.
+func (g *generator) inputType(typ *ast.Type) string {
+	if typ.Elem != nil {
+		return "[]" + g.inputType(typ.Elem)
+	}
+	var t string
+	switch def := g.schema.Types[typ.NamedType]; def.Kind {
+	case ast.Scalar:
+		t = g.scalarType(def.Name)
+		if g.nillableScalar(def.Name) {
+			return t
+		}
+	default:
+		t = g.useType(def)
+	}
+	return nullable(t, typ.NonNull)
+}
+
+// nullable returns a pointer to the type for nullable types, unless the type can be nil already.
+func nullable(t string, nonNull bool) string {
+	if nonNull || t == "interface{}" || strings.HasPrefix(t, "*") ||
+		strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[") {
+		return t
+	}
+	return "*" + t
+}
+
+// useImport adds the import of a package that is not renamed.
+func (g *generator) useImport(importPath string) {
+	if _, ok := g.imports[importPath]; !ok {
+		g.imports[importPath] = ""
+	}
+}
+
+// nillableScalar reports whether the Go type of the scalar type can hold a null without a pointer.
+func (g *generator) nillableScalar(name string) bool {
+	t, ok := g.scalars[name]
+	return !ok || t.nillable
+}
+
+// scalarType returns the Go type that the scalar type is mapped to, and adds its import.
+func (g *generator) scalarType(name string) string {
+	t, ok := g.scalars[name]
+	if !ok {
+		t = rawMessage
+	}
+	if t.importPath != "" {
+		g.useImport(t.importPath)
+	}
+	return t.expr
+}
+
+// useType returns the name of the Go type for an enum or input object type, and marks the type and
+// the types of its fields to be generated.
+func (g *generator) useType(def *ast.Definition) string {
+	if _, ok := g.types[def.Name]; !ok {
+		g.types[def.Name] = def
+		if def.Kind == ast.InputObject {
+			for _, f := range def.Fields {
+				g.inputType(f.Type)
+			}
+		}
+	}
+	return goName(def.Name)
+}
+
+// schemaTypes generates the enum and input object types that are used by the operations.
+func (g *generator) schemaTypes() {
+	names := make([]string, 0, len(g.types))
+	for name := range g.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		def := g.types[name]
+		typeName := goName(def.Name)
+		switch def.Kind {
+		case ast.Enum:
+			g.printf("// %s is the %s enum.\n", typeName, def.Name)
+			if def.Description != "" {
+				g.printf("//\n")
+				g.comment("", def.Description)
+			}
+			g.printf("type %s string\n\n", typeName)
+			g.printf("const (\n")
+			for _, v := range def.EnumValues {
+				g.comment("\t", v.Description)
+				g.printf("\t%s%s %s = %q\n", typeName, goName(v.Name), typeName, v.Name)
+			}
+			g.printf(")\n\n")
+		case ast.InputObject:
+			g.printf("// %s is the %s input object.\n", typeName, def.Name)
+			if def.Description != "" {
+				g.printf("//\n")
+				g.comment("", def.Description)
+			}
+			g.printf("type %s struct {\n", typeName)
+			for _, f := range def.Fields {
+				tag := f.Name
+				if !f.Type.NonNull {
+					tag += ",omitempty"
+				}
+				g.comment("\t", f.Description)
+				g.printf("\t%s %s `json:%q`\n", goName(f.Name), g.inputType(f.Type), tag)
+			}
+			g.printf("}\n\n")
+		}
+	}
+}
+
+// quote returns a Go string literal for the text, preferring a raw string literal.
+func quote(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
+
+// position returns the file and line of a position for error messages.
+func position(pos *ast.Position) string {
+	if pos == nil || pos.Src == nil {
+		return "(unknown)"
+	}
+	return fmt.Sprintf("%s:%d", pos.Src.Name, pos.Line)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SuiteGenerate struct {
+	suite.Suite
+	dirs []string
+}
+
+func TestSuiteGenerate(t *testing.T) {
+	s := SuiteGenerate{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteGenerate) tempDir() string {
+	dir, err := ioutil.TempDir("", "gqlclient-gen")
+	s.Require().NoError(err)
+	s.dirs = append(s.dirs, dir)
+	return dir
+}
+
+func (s *SuiteGenerate) TearDownTest() {
+	for _, dir := range s.dirs {
+		_ = os.RemoveAll(dir)
+	}
+	s.dirs = nil
+}
+
+func (s *SuiteGenerate) writeFile(dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	s.Require().NoError(os.MkdirAll(filepath.Dir(path), 0755))
+	s.Require().NoError(ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// generateFiles generates the code for a config, a schema and operation files in a temporary
+// directory.
+func (s *SuiteGenerate) generateFiles(config, schema string, operations map[string]string) ([]byte, error) {
+	dir := s.tempDir()
+	s.writeFile(dir, "schema.graphql", schema)
+	for name, content := range operations {
+		s.writeFile(dir, filepath.Join("operations", name), content)
+	}
+	configPath := s.writeFile(dir, "gqlclient.yml", config)
+
+	if err := run(configPath); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filepath.Join(dir, "generated.go"))
+}
+
+const testConfig = `
+schema: schema.graphql
+operations: operations
+package: api
+`
+
+const testSchema = `
+type Query {
+	user(id: ID!): User
+}
+type Subscription {
+	userAdded: User!
+}
+type User {
+	id: ID!
+	type: String
+}
+`
+
+// TestGenerate compares the generated code for the testdata with generated.golden. Regenerate it
+// with: go run . -config testdata/gqlclient.yml
+func (s *SuiteGenerate) TestGenerate() {
+	cfg, err := loadConfig("testdata/gqlclient.yml")
+	s.Require().NoError(err)
+	schema, err := loadSchema(cfg.Schema)
+	s.Require().NoError(err)
+	doc, err := loadOperations(schema, cfg.Operations)
+	s.Require().NoError(err)
+
+	src, err := generate(schema, doc, cfg)
+	s.Require().NoError(err)
+	expected, err := ioutil.ReadFile("testdata/generated.golden")
+	s.Require().NoError(err)
+	s.Equal(string(expected), string(src))
+}
+
+func (s *SuiteGenerate) TestGenerateKeywordVariable() {
+	src, err := s.generateFiles(testConfig, testSchema, map[string]string{
+		"user.graphql": `query User($type: ID!) { user(id: $type) { type } }`,
+	})
+	s.Require().NoError(err)
+	s.Contains(string(src), "func User(ctx context.Context, c *gqlclient.Client, type_ string) (*UserResponse, error)")
+	s.Contains(string(src), `gqlclient.WithVar("type", type_)`)
+}
+
+func (s *SuiteGenerate) TestGenerateUnmappedScalar() {
+	src, err := s.generateFiles(testConfig, testSchema+"scalar JSON\nextend type User { data: JSON }", map[string]string{
+		"user.graphql": `query User($id: ID!) { user(id: $id) { data } }`,
+	})
+	s.Require().NoError(err)
+	s.Contains(string(src), `"encoding/json"`)
+	s.Contains(string(src), "Data json.RawMessage `json:\"data\"`")
+
+	// Named slice and map types of the standard library are not made a pointer either.
+	src, err = s.generateFiles(testConfig+"scalars:\n  IP: net.IP\n", testSchema+"scalar IP\nextend type User { ip(mask: IP): IP }", map[string]string{
+		"user.graphql": `query User($id: ID!, $ip: IP) { user(id: $id) { ip(mask: $ip) } }`,
+	})
+	s.Require().NoError(err)
+	s.Contains(string(src), "IP net.IP `json:\"ip\"`")
+	s.Contains(string(src), "ip net.IP")
+}
+
+func (s *SuiteGenerate) TestGenerateConditionalFragment() {
+	schema := testSchema + `
+		interface Node { id: ID! }
+		extend type User implements Node
+		type Group implements Node { id: ID! }
+		extend type Query { node(id: ID!): Node }
+	`
+	src, err := s.generateFiles(testConfig, schema, map[string]string{
+		"node.graphql": `
+			query Node($id: ID!) { node(id: $id) { ...NodeFields } }
+			fragment NodeFields on Node { id ... on User { type } }
+		`,
+	})
+	s.Require().NoError(err)
+	// The fragment selects fields for User only, so its fields are added to the types directly.
+	s.NotContains(string(src), "type NodeFields struct")
+	s.Contains(string(src), "type NodeNodeUser struct {\n\tTypename string  `json:\"__typename\"`\n\tID       string  `json:\"id\"`\n\tType     *string `json:\"type\"`\n}")
+	s.Contains(string(src), "__typename\n\t\t... NodeFields")
+}
+
+func (s *SuiteGenerate) TestGenerateInvalidOperation() {
+	_, err := s.generateFiles(testConfig, testSchema, map[string]string{
+		"user.graphql": `query User($id: ID!) { user(id: $id) { name } }`,
+	})
+	s.Error(err)
+}
+
+func (s *SuiteGenerate) TestGenerateAnonymousOperation() {
+	_, err := s.generateFiles(testConfig, testSchema, map[string]string{
+		"user.graphql": `query ($id: ID!) { user(id: $id) { id } }`,
+	})
+	s.Require().Error(err)
+	s.Contains(err.Error(), "user.graphql:1: operations must be named")
+}
+
+func (s *SuiteGenerate) TestGenerateSubscription() {
+	_, err := s.generateFiles(testConfig, testSchema, map[string]string{
+		"user.graphql": `subscription UserAdded { userAdded { id } }`,
+	})
+	s.Error(err)
+}
+
+func (s *SuiteGenerate) TestGenerateInvalidScalar() {
+	_, err := s.generateFiles(testConfig+"scalars:\n  ID: .Invalid\n", testSchema, map[string]string{
+		"user.graphql": `query User($id: ID!) { user(id: $id) { id } }`,
+	})
+	s.EqualError(err, `scalar ID: invalid Go type ".Invalid"`)
+}
+
+func (s *SuiteGenerate) TestLoadConfigUnknownField() {
+	dir := s.tempDir()
+	path := s.writeFile(dir, "gqlclient.yml", testConfig+"unknown: true\n")
+
+	_, err := loadConfig(path)
+	s.Error(err)
+}
+
+func (s *SuiteGenerate) TestParseGoType() {
+	tests := map[string]goType{
+		"string":                          {expr: "string"},
+		"time.Time":                       {importPath: "time", expr: "time.Time"},
+		"*time.Time":                      {importPath: "time", expr: "*time.Time"},
+		"encoding/json.RawMessage":        {importPath: "encoding/json", expr: "json.RawMessage", nillable: true},
+		"github.com/google/uuid.UUID":     {importPath: "github.com/google/uuid", expr: "uuid.UUID"},
+		"github.com/shopspring/v2.Number": {importPath: "github.com/shopspring/v2", expr: "shopspring.Number"},
+	}
+	for mapping, expected := range tests {
+		t, err := parseGoType(mapping)
+		s.NoError(err, mapping)
+		s.Equal(expected, t, mapping)
+	}
+}
+
+func (s *SuiteGenerate) TestGoName() {
+	tests := map[string]string{
+		"user":        "User",
+		"userId":      "UserID",
+		"imageURL":    "ImageURL",
+		"IN_PROGRESS": "InProgress",
+		"first_name":  "FirstName",
+		"__typename":  "Typename",
+		"HTTPServer":  "HTTPServer",
+		"2fa":         "X2fa",
+	}
+	for name, expected := range tests {
+		s.Equal(expected, goName(name), name)
+	}
+}
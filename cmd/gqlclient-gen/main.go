@@ -0,0 +1,121 @@
+// Command gqlclient-gen generates typed Go functions for the GraphQL operations in a directory of
+// .graphql files. For every operation it generates a response type and a function that executes the
+// operation with a gqlclient.Client, along with the enum and input object types that are used by the
+// operations.
+//
+// Fragments are generated as struct types that are embedded in the types that select them. Fields
+// with an interface or union type are generated as Go interfaces, with a struct type for every
+// possible type that is chosen using the __typename of the value.
+//
+// The generator is configured with a YAML file:
+//  schema: schema.graphql   # SDL file, directory with SDL files, or introspection .json file
+//  operations: operations   # directory with .graphql operation files
+//  output: generated.go
+//  package: api
+//  scalars:
+//    DateTime: time.Time
+//    UUID: github.com/google/uuid.UUID
+//
+// Run it with the path of the config file, for example from a go:generate directive:
+//  //go:generate gqlclient-gen -config gqlclient.yml
+//
+// The manifest subcommand writes a manifest of trusted documents for gqlclient.WithTrustedDocuments.
+// It extracts the operations from the .graphql files and the Go string literals in the files and
+// directories that are passed to it, and writes their normalized documents by their SHA-256 hashes:
+//  gqlclient-gen manifest -o manifest.json .
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+
+	gql "github.com/weavedev/go-gqlclient"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "manifest" {
+		if err := runManifest(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "gqlclient-gen manifest: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	configPath := flag.String("config", "gqlclient.yml", "path to the config file")
+	flag.Parse()
+
+	if err := run(*configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "gqlclient-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the code for the config file.
+func run(configPath string) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	schema, err := loadSchema(cfg.Schema)
+	if err != nil {
+		return err
+	}
+	doc, err := loadOperations(schema, cfg.Operations)
+	if err != nil {
+		return err
+	}
+	src, err := generate(schema, doc, cfg)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cfg.Output, src, 0644)
+}
+
+// loadSchema loads the schema from the result of an introspection query for .json files, and from
+// SDL otherwise.
+func loadSchema(path string) (*ast.Schema, error) {
+	if filepath.Ext(path) == ".json" {
+		return gql.LoadSchemaJSON(path)
+	}
+	return gql.LoadSchemaSDL(path)
+}
+
+// loadOperations parses the .graphql files in a directory into a single document, so that fragments
+// can be shared between files, and validates it against the schema.
+func loadOperations(schema *ast.Schema, dir string) (*ast.QueryDocument, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.graphql"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	doc := &ast.QueryDocument{}
+	for _, file := range files {
+		input, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fileDoc, gerr := parser.ParseQuery(&ast.Source{Name: file, Input: string(input)})
+		if gerr != nil {
+			return nil, gerr
+		}
+		doc.Operations = append(doc.Operations, fileDoc.Operations...)
+		doc.Fragments = append(doc.Fragments, fileDoc.Fragments...)
+	}
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("no operations in %s", dir)
+	}
+
+	if errs := validator.Validate(schema, doc); len(errs) > 0 {
+		return nil, &gql.ValidationError{Errors: errs}
+	}
+	return doc, nil
+}
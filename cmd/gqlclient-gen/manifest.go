@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	goast "go/ast"
+	goparser "go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+
+	gql "github.com/weavedev/go-gqlclient"
+)
+
+// runManifest writes the manifest of trusted documents for the files and directories in the
+// arguments.
+func runManifest(args []string) error {
+	flags := flag.NewFlagSet("manifest", flag.ExitOnError)
+	output := flags.String("o", "manifest.json", "path of the manifest file")
+	_ = flags.Parse(args)
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	var e extractor
+	for _, path := range paths {
+		if err := e.walk(path); err != nil {
+			return err
+		}
+	}
+	manifest, err := e.manifest()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(*output, append(data, '\n'), 0644)
+}
+
+// extractor extracts the documents that are sent by an application from its .graphql files and the
+// string literals in its Go source. Every operation in a .graphql file is a document, like in
+// gqlclient.Documents, while a Go string literal with operations is a document as a whole. The
+// fragments that are used by a document are added to it, also when they are defined elsewhere.
+type extractor struct {
+	documents []*ast.QueryDocument
+	fragments ast.FragmentDefinitionList
+}
+
+// walk extracts the documents from a file, or from the files in a directory and its
+// subdirectories. Hidden, vendor and testdata directories are skipped.
+func (e *extractor) walk(root string) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch filepath.Ext(name) {
+		case ".graphql", ".gql":
+			return e.graphqlFile(path)
+		case ".go":
+			return e.goFile(path)
+		}
+		return nil
+	})
+}
+
+// graphqlFile extracts the operations and fragments of a .graphql file.
+func (e *extractor) graphqlFile(path string) error {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc, gerr := parser.ParseQuery(&ast.Source{Name: path, Input: string(input)})
+	if gerr != nil {
+		return gerr
+	}
+	for _, op := range doc.Operations {
+		e.documents = append(e.documents, &ast.QueryDocument{Operations: ast.OperationList{op}})
+	}
+	return e.addFragments(doc.Fragments)
+}
+
+// goFile extracts the string literals of a Go file that are GraphQL documents.
+func (e *extractor) goFile(path string) error {
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, path, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	goast.Inspect(file, func(node goast.Node) bool {
+		lit, ok := node.(*goast.BasicLit)
+		if !ok || lit.Kind != token.STRING || err != nil {
+			return true
+		}
+		s, uerr := strconv.Unquote(lit.Value)
+		if uerr != nil || !isDocument(s) {
+			return true
+		}
+		pos := fset.Position(lit.Pos())
+		doc, gerr := parser.ParseQuery(&ast.Source{Name: fmt.Sprintf("%s:%d", pos.Filename, pos.Line), Input: s})
+		if gerr != nil {
+			// The string looks like a document, but it is not one.
+			return true
+		}
+		if len(doc.Operations) > 0 {
+			e.documents = append(e.documents, doc)
+		}
+		err = e.addFragments(doc.Fragments)
+		return true
+	})
+	return err
+}
+
+// isDocument reports whether a string starts like a GraphQL document.
+func isDocument(s string) bool {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "{") {
+		return true
+	}
+	for _, keyword := range []string{"query", "mutation", "subscription", "fragment"} {
+		if strings.HasPrefix(s, keyword) && len(s) > len(keyword) && strings.ContainsRune(" \t\r\n({@", rune(s[len(keyword)])) {
+			return true
+		}
+	}
+	return false
+}
+
+// addFragments adds fragments that can be used by any document. A fragment can be defined more than
+// once, for example in the documents of generated code, but only with the same selections.
+func (e *extractor) addFragments(fragments ast.FragmentDefinitionList) error {
+	for _, frag := range fragments {
+		other := e.fragments.ForName(frag.Name)
+		if other == nil {
+			e.fragments = append(e.fragments, frag)
+			continue
+		}
+		if minifyFragment(frag) != minifyFragment(other) {
+			return fmt.Errorf("%s: fragment %s is also defined differently at %s",
+				position(frag.Position), frag.Name, position(other.Position))
+		}
+	}
+	return nil
+}
+
+// minifyFragment returns the minified text of a fragment, to compare fragments.
+func minifyFragment(frag *ast.FragmentDefinition) string {
+	query, _ := gql.MinifyQuery(formatDocument(&ast.QueryDocument{Fragments: ast.FragmentDefinitionList{frag}}))
+	return query
+}
+
+func formatDocument(doc *ast.QueryDocument) string {
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatQueryDocument(doc)
+	return buf.String()
+}
+
+// manifest returns the normalized documents by their hashes.
+func (e *extractor) manifest() (map[string]string, error) {
+	manifest := make(map[string]string, len(e.documents))
+	for _, doc := range e.documents {
+		var fragments ast.FragmentDefinitionList
+		for _, op := range doc.Operations {
+			var err error
+			if fragments, err = e.usedFragments(op.SelectionSet, doc.Fragments, fragments); err != nil {
+				return nil, err
+			}
+		}
+		query, err := gql.NormalizeQuery(formatDocument(&ast.QueryDocument{Operations: doc.Operations, Fragments: fragments}))
+		if err != nil {
+			return nil, err
+		}
+		hash, err := gql.QueryHash(query)
+		if err != nil {
+			return nil, err
+		}
+		manifest[hash] = query
+	}
+	return manifest, nil
+}
+
+// usedFragments returns the fragments that are used by the selection set, including the fragments
+// that are used by those fragments. Fragments are looked up in the document first.
+func (e *extractor) usedFragments(selections ast.SelectionSet, own, list ast.FragmentDefinitionList) (ast.FragmentDefinitionList, error) {
+	var err error
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			list, err = e.usedFragments(selection.SelectionSet, own, list)
+		case *ast.InlineFragment:
+			list, err = e.usedFragments(selection.SelectionSet, own, list)
+		case *ast.FragmentSpread:
+			if list.ForName(selection.Name) != nil {
+				continue
+			}
+			def := own.ForName(selection.Name)
+			if def == nil {
+				def = e.fragments.ForName(selection.Name)
+			}
+			if def == nil {
+				return nil, fmt.Errorf("%s: fragment %s is not defined", position(selection.Position), selection.Name)
+			}
+			list, err = e.usedFragments(def.SelectionSet, own, append(list, def))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	gql "github.com/weavedev/go-gqlclient"
+)
+
+const manifestGoFile = "package app\n\n" +
+	"const viewerQuery = `query Viewer { viewer { ...UserFields } }`\n\n" +
+	"const userFields = `\n\tfragment UserFields on User { id name }\n`\n\n" +
+	"var notDocuments = []string{\"hello\", `{\"query\": true}`, \"query\"}\n"
+
+func (s *SuiteGenerate) TestManifest() {
+	dir := s.tempDir()
+	s.writeFile(dir, "operations/user.graphql", `
+		query GetUser($id: ID!) { user(id: $id) { ...UserFields } }
+		mutation Rename($id: ID!, $name: String!) { rename(id: $id, name: $name) { id } }`)
+	s.writeFile(dir, "operations/fragments.graphql", `fragment UserFields on User { id, name }`)
+	s.writeFile(dir, "app.go", manifestGoFile)
+	s.writeFile(dir, "testdata/skipped.graphql", `query Skipped { viewer { id } }`)
+	output := filepath.Join(dir, "manifest.json")
+
+	s.Require().NoError(runManifest([]string{"-o", output, dir}))
+	data, err := ioutil.ReadFile(output)
+	s.Require().NoError(err)
+	var documents map[string]string
+	s.Require().NoError(json.Unmarshal(data, &documents))
+
+	s.Len(documents, 3)
+	for hash, query := range documents {
+		expected, err := gql.QueryHash(query)
+		s.Require().NoError(err)
+		s.Equal(expected, hash)
+	}
+	hash, err := gql.QueryHash("query Viewer{viewer{...UserFields}}fragment UserFields on User{id name}")
+	s.Require().NoError(err)
+	s.Equal("query Viewer{viewer{...UserFields}}fragment UserFields on User{id name}", documents[hash])
+
+	// The queries of the Documents registry are in the manifest.
+	manifest, err := gql.ParseManifest(data)
+	s.Require().NoError(err)
+	docs, err := gql.LoadDocuments(os.DirFS(filepath.Join(dir, "operations")))
+	s.Require().NoError(err)
+	for _, name := range docs.Names() {
+		query, _ := docs.Query(name)
+		_, ok := manifest.DocumentID(query)
+		s.True(ok, name)
+	}
+}
+
+func (s *SuiteGenerate) TestManifestErrors() {
+	dir := s.tempDir()
+	s.writeFile(dir, "a.graphql", `fragment UserFields on User { id }`)
+	s.writeFile(dir, "b.graphql", `fragment UserFields on User { name }`)
+	err := runManifest([]string{"-o", filepath.Join(dir, "manifest.json"), dir})
+	s.Require().Error(err)
+	s.Contains(err.Error(), "b.graphql:1: fragment UserFields is also defined differently at ")
+
+	dir = s.tempDir()
+	s.writeFile(dir, "a.graphql", `query GetUser { user(id: 1) { ...UserFields } }`)
+	err = runManifest([]string{"-o", filepath.Join(dir, "manifest.json"), dir})
+	s.Require().Error(err)
+	s.Contains(err.Error(), "a.graphql:1: fragment UserFields is not defined")
+}
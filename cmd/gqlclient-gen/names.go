@@ -0,0 +1,70 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true, "EOF": true,
+	"HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true, "JSON": true,
+	"SQL": true, "TCP": true, "TLS": true, "TTL": true, "UI": true, "URI": true,
+	"URL": true, "UTF8": true, "UUID": true, "XML": true,
+}
+
+// reservedParams are the names that the generated functions use, next to the variables.
+var reservedParams = map[string]bool{
+	"ctx": true, "c": true, "opts": true, "resp": true, "err": true,
+	"context": true, "gqlclient": true,
+}
+
+// goName converts a GraphQL name into an exported Go identifier, for example "userId" into "UserID"
+// and "IN_PROGRESS" into "InProgress".
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		upper := strings.ToUpper(word)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		if word == upper {
+			word = strings.ToLower(word)
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	if b.Len() == 0 || unicode.IsDigit(rune(b.String()[0])) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
+
+// splitWords splits a name into words at underscores and at upper case letters that follow a lower
+// case letter or a digit.
+func splitWords(name string) []string {
+	var words []string
+	for _, part := range strings.Split(name, "_") {
+		start := 0
+		for i := 1; i < len(part); i++ {
+			prev := rune(part[i-1])
+			if unicode.IsUpper(rune(part[i])) && (unicode.IsLower(prev) || unicode.IsDigit(prev)) {
+				words = append(words, part[start:i])
+				start = i
+			}
+		}
+		if start < len(part) {
+			words = append(words, part[start:])
+		}
+	}
+	return words
+}
+
+// paramName returns the name of the function parameter for a variable.
+func paramName(variable string) string {
+	if token.Lookup(variable).IsKeyword() || reservedParams[variable] {
+		return variable + "_"
+	}
+	return variable
+}
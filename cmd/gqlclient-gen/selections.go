@@ -0,0 +1,385 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// selectedField is a field in a selection set, with the selections of all the occurrences of the
+// field merged.
+type selectedField struct {
+	field      *ast.Field
+	selections ast.SelectionSet
+}
+
+// structItems are the fields and the embedded fragments of a struct type.
+type structItems struct {
+	fields    []*selectedField
+	fragments ast.FragmentDefinitionList
+}
+
+// interfaceType is the Go interface that is generated for a field with an interface or union type.
+type interfaceType struct {
+	name string
+	// getters are the leaf fields that are selected for every possible type.
+	getters []*selectedField
+	// fragments are the embedded fragments that apply to every possible type.
+	fragments ast.FragmentDefinitionList
+}
+
+// addTypename adds the __typename field to the selection sets of interfaces and unions that do not
+// select it already.
+func (g *generator) addTypename(selections ast.SelectionSet) {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Definition != nil && len(selection.SelectionSet) > 0 &&
+				g.schema.Types[selection.Definition.Type.Name()].IsAbstractType() &&
+				!hasTypename(selection.SelectionSet) {
+				typename := &ast.Field{Alias: "__typename", Name: "__typename"}
+				selection.SelectionSet = append(ast.SelectionSet{typename}, selection.SelectionSet...)
+			}
+			g.addTypename(selection.SelectionSet)
+		case *ast.InlineFragment:
+			g.addTypename(selection.SelectionSet)
+		}
+	}
+}
+
+// hasTypename reports whether the __typename field is selected, without an alias.
+func hasTypename(selections ast.SelectionSet) bool {
+	for _, selection := range selections {
+		if f, ok := selection.(*ast.Field); ok && f.Name == "__typename" && f.Alias == "__typename" {
+			return true
+		}
+	}
+	return false
+}
+
+// collect adds the items of a selection set that apply to the type. Fragments that can be embedded
+// are added as a whole, the selections of other fragments that apply are added as if they were
+// selected directly.
+func (g *generator) collect(items *structItems, selections ast.SelectionSet, def *ast.Definition) {
+outer:
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Definition == nil {
+				// The __typename field that was added by addTypename.
+				continue
+			}
+			for _, f := range items.fields {
+				if f.field.Alias == selection.Alias {
+					f.selections = append(f.selections, selection.SelectionSet...)
+					continue outer
+				}
+			}
+			items.fields = append(items.fields, &selectedField{
+				field:      selection,
+				selections: append(ast.SelectionSet{}, selection.SelectionSet...),
+			})
+		case *ast.InlineFragment:
+			if g.applies(selection.TypeCondition, def) {
+				g.collect(items, selection.SelectionSet, def)
+			}
+		case *ast.FragmentSpread:
+			frag := g.doc.Fragments.ForName(selection.Name)
+			if !g.applies(frag.TypeCondition, def) {
+				continue
+			}
+			if !g.embeddable(frag) {
+				g.collect(items, frag.SelectionSet, def)
+				continue
+			}
+			if items.fragments.ForName(frag.Name) == nil {
+				items.fragments = append(items.fragments, frag)
+			}
+		}
+	}
+}
+
+// applies reports whether a type condition applies to every possible type of the type.
+func (g *generator) applies(condition string, def *ast.Definition) bool {
+	if condition == "" || condition == def.Name {
+		return true
+	}
+	possible := g.schema.GetPossibleTypes(g.schema.Types[condition])
+outer:
+	for _, t := range g.schema.GetPossibleTypes(def) {
+		for _, p := range possible {
+			if p == t {
+				continue outer
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// embeddable reports whether a fragment is generated as a struct type that is embedded. Fragments
+// on interfaces and unions are only embedded when they select the same fields for every possible
+// type.
+func (g *generator) embeddable(frag *ast.FragmentDefinition) bool {
+	def := g.schema.Types[frag.TypeCondition]
+	return def.Kind == ast.Object || g.unconditional(frag.SelectionSet, def)
+}
+
+// unconditional reports whether all the selections apply to every possible type of the type.
+func (g *generator) unconditional(selections ast.SelectionSet, def *ast.Definition) bool {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.InlineFragment:
+			if !g.applies(selection.TypeCondition, def) || !g.unconditional(selection.SelectionSet, def) {
+				return false
+			}
+		case *ast.FragmentSpread:
+			frag := g.doc.Fragments.ForName(selection.Name)
+			if !g.applies(frag.TypeCondition, def) || !g.unconditional(frag.SelectionSet, def) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// embed returns the name of the struct type of a fragment, and marks it to be generated.
+func (g *generator) embed(frag *ast.FragmentDefinition) string {
+	if g.embedded.ForName(frag.Name) == nil {
+		g.embedded = append(g.embedded, frag)
+	}
+	return goName(frag.Name)
+}
+
+// fragmentTypes generates the struct types of the embedded fragments.
+func (g *generator) fragmentTypes() {
+	// Generating a fragment type can embed more fragments.
+	for i := 0; i < len(g.embedded); i++ {
+		frag := g.embedded[i]
+		name := goName(frag.Name)
+		description := fmt.Sprintf("the %s fragment on %s", frag.Name, frag.TypeCondition)
+		g.structType(name, description, name, frag.SelectionSet, g.schema.Types[frag.TypeCondition], nil)
+	}
+}
+
+// structType generates a struct type for the selections that apply to the type, followed by the
+// types of its fields. The names of those types start with the prefix. The struct type of a
+// possible type of an interface or union implements the Go interface iface.
+func (g *generator) structType(typeName, description, prefix string, selections ast.SelectionSet, def *ast.Definition, iface *interfaceType) {
+	var items structItems
+	g.collect(&items, selections, def)
+	fields := items.fields[:0:0]
+	for _, f := range items.fields {
+		if iface == nil || f.field.Alias != "__typename" {
+			fields = append(fields, f)
+		}
+	}
+
+	g.printf("// %s is %s.\n", typeName, description)
+	g.printf("type %s struct {\n", typeName)
+	if iface != nil {
+		g.printf("\tTypename string `json:\"__typename\"`\n")
+	}
+	for _, frag := range items.fragments {
+		g.printf("\t%s\n", g.embed(frag))
+	}
+	var nested []*selectedField
+	hasInterfaces := false
+	for _, f := range fields {
+		fieldName := goName(f.field.Alias)
+		g.comment("\t", f.field.Definition.Description)
+		g.printf("\t%s %s `json:%q`\n", fieldName, g.outputType(f.field.Definition.Type, prefix+fieldName), f.field.Alias)
+		if len(f.selections) > 0 {
+			nested = append(nested, f)
+			hasInterfaces = hasInterfaces || g.isAbstract(f)
+		}
+	}
+	g.printf("}\n\n")
+
+	if iface != nil {
+		g.implement(typeName, iface)
+	}
+	if len(items.fragments) > 0 || hasInterfaces {
+		g.unmarshalJSON(typeName, prefix, fields, items.fragments, iface != nil)
+	}
+
+	for _, f := range nested {
+		name := prefix + goName(f.field.Alias)
+		description := fmt.Sprintf("the %s field of %s", f.field.Alias, typeName)
+		fieldDef := g.schema.Types[f.field.Definition.Type.Name()]
+		if fieldDef.IsAbstractType() {
+			g.interfaceType(name, description, f.selections, fieldDef)
+		} else {
+			g.structType(name, description, name, f.selections, fieldDef, nil)
+		}
+	}
+}
+
+// isAbstract reports whether the type of the field is an interface or union.
+func (g *generator) isAbstract(f *selectedField) bool {
+	return g.schema.Types[f.field.Definition.Type.Name()].IsAbstractType()
+}
+
+// outputType returns the Go type for a field of a response. Object types use the named struct type,
+// and interfaces and unions the named interface type.
+func (g *generator) outputType(typ *ast.Type, structName string) string {
+	if typ.Elem != nil {
+		return "[]" + g.outputType(typ.Elem, structName)
+	}
+	var t string
+	switch def := g.schema.Types[typ.NamedType]; def.Kind {
+	case ast.Scalar:
+		t = g.scalarType(def.Name)
+		if g.nillableScalar(def.Name) {
+			return t
+		}
+	case ast.Enum:
+		t = g.useType(def)
+	case ast.Interface, ast.Union:
+		return structName
+	default:
+		t = structName
+	}
+	return nullable(t, typ.NonNull)
+}
+
+// interfaceType generates a Go interface for a field with an interface or union type, with a struct
+// type that implements it for every possible type, and a function that decodes the field into the
+// struct type for its __typename.
+func (g *generator) interfaceType(name, description string, selections ast.SelectionSet, def *ast.Definition) {
+	var common structItems
+	g.collect(&common, selections, def)
+	iface := &interfaceType{name: name, fragments: common.fragments}
+	for _, f := range common.fields {
+		if len(f.selections) == 0 && f.field.Alias != "__typename" {
+			iface.getters = append(iface.getters, f)
+		}
+	}
+
+	possible := append([]*ast.Definition{}, g.schema.GetPossibleTypes(def)...)
+	sort.Slice(possible, func(i, j int) bool {
+		return possible[i].Name < possible[j].Name
+	})
+	typeNames := make([]string, len(possible))
+	for i, p := range possible {
+		typeNames[i] = name + goName(p.Name)
+	}
+
+	g.printf("// %s is %s.\n", name, description)
+	g.printf("// It is implemented by %s.\n", strings.Join(typeNames, ", "))
+	g.printf("type %s interface {\n", name)
+	g.printf("\tis%s()\n", name)
+	g.printf("\t// GetTypename returns the __typename of the value.\n")
+	g.printf("\tGetTypename() string\n")
+	for _, frag := range iface.fragments {
+		g.printf("\t// Get%s returns the %s fragment.\n", goName(frag.Name), frag.Name)
+		g.printf("\tGet%s() %s\n", goName(frag.Name), g.embed(frag))
+	}
+	for _, f := range iface.getters {
+		g.printf("\t// Get%s returns the %s field.\n", goName(f.field.Alias), f.field.Alias)
+		g.printf("\tGet%s() %s\n", goName(f.field.Alias), g.outputType(f.field.Definition.Type, ""))
+	}
+	g.printf("}\n\n")
+
+	g.useImport("encoding/json")
+	g.useImport("fmt")
+	g.printf("// unmarshal%s decodes a %s into the type for its __typename.\n", name, name)
+	g.printf("func unmarshal%s(data json.RawMessage) (%s, error) {\n", name, name)
+	g.printf("\tif len(data) == 0 || string(data) == \"null\" {\n\t\treturn nil, nil\n\t}\n")
+	g.printf("\tvar typename struct {\n\t\tTypename string `json:\"__typename\"`\n\t}\n")
+	g.printf("\tif err := json.Unmarshal(data, &typename); err != nil {\n\t\treturn nil, err\n\t}\n")
+	g.printf("\tvar v %s\n\tswitch typename.Typename {\n", name)
+	for i, p := range possible {
+		g.printf("\tcase %q:\n\t\tv = &%s{}\n", p.Name, typeNames[i])
+	}
+	g.printf("\tdefault:\n\t\treturn nil, fmt.Errorf(\"unexpected __typename %%q for %s\", typename.Typename)\n\t}\n", name)
+	g.printf("\tif err := json.Unmarshal(data, v); err != nil {\n\t\treturn nil, err\n\t}\n")
+	g.printf("\treturn v, nil\n}\n\n")
+
+	for i, p := range possible {
+		description := fmt.Sprintf("the %s implementation of %s", p.Name, name)
+		g.structType(typeNames[i], description, typeNames[i], selections, p, iface)
+	}
+}
+
+// implement generates the methods of the Go interface for the struct type of a possible type.
+func (g *generator) implement(typeName string, iface *interfaceType) {
+	g.printf("func (v *%s) is%s() {}\n\n", typeName, iface.name)
+	g.printf("// GetTypename returns the __typename of the value.\n")
+	g.printf("func (v *%s) GetTypename() string {\n\treturn v.Typename\n}\n\n", typeName)
+	for _, frag := range iface.fragments {
+		fragName := goName(frag.Name)
+		g.printf("// Get%s returns the %s fragment.\n", fragName, frag.Name)
+		g.printf("func (v *%s) Get%s() %s {\n\treturn v.%s\n}\n\n", typeName, fragName, fragName, fragName)
+	}
+	for _, f := range iface.getters {
+		fieldName := goName(f.field.Alias)
+		g.printf("// Get%s returns the %s field.\n", fieldName, f.field.Alias)
+		g.printf("func (v *%s) Get%s() %s {\n\treturn v.%s\n}\n\n", typeName, fieldName, g.outputType(f.field.Definition.Type, ""), fieldName)
+	}
+}
+
+// unmarshalJSON generates an UnmarshalJSON method for a struct type with embedded fragments or
+// fields with an interface or union type. The fields and every fragment are decoded separately, so
+// that fragments can select the same fields.
+func (g *generator) unmarshalJSON(typeName, prefix string, fields []*selectedField, fragments ast.FragmentDefinitionList, typename bool) {
+	g.useImport("encoding/json")
+	g.printf("// UnmarshalJSON decodes the fields and the embedded fragments of %s.\n", typeName)
+	g.printf("func (v *%s) UnmarshalJSON(data []byte) error {\n", typeName)
+	var interfaces []*selectedField
+	if len(fields) > 0 || typename {
+		g.printf("\tvar fields struct {\n")
+		if typename {
+			g.printf("\t\tTypename string `json:\"__typename\"`\n")
+		}
+		for _, f := range fields {
+			fieldName := goName(f.field.Alias)
+			typ := g.outputType(f.field.Definition.Type, prefix+fieldName)
+			if len(f.selections) > 0 && g.isAbstract(f) {
+				typ = strings.Repeat("[]", strings.Count(typ, "[]")) + "json.RawMessage"
+				interfaces = append(interfaces, f)
+			}
+			g.printf("\t\t%s %s `json:%q`\n", fieldName, typ, f.field.Alias)
+		}
+		g.printf("\t}\n")
+		g.printf("\tif err := json.Unmarshal(data, &fields); err != nil {\n\t\treturn err\n\t}\n")
+	}
+	if typename {
+		g.printf("\tv.Typename = fields.Typename\n")
+	}
+	for _, f := range fields {
+		if len(f.selections) == 0 || !g.isAbstract(f) {
+			fieldName := goName(f.field.Alias)
+			g.printf("\tv.%s = fields.%s\n", fieldName, fieldName)
+		}
+	}
+	for _, frag := range fragments {
+		g.printf("\tif err := json.Unmarshal(data, &v.%s); err != nil {\n\t\treturn err\n\t}\n", goName(frag.Name))
+	}
+	if len(interfaces) > 0 {
+		g.printf("\tvar err error\n")
+	}
+	for _, f := range interfaces {
+		fieldName := goName(f.field.Alias)
+		g.unmarshalInterface("v."+fieldName, "fields."+fieldName, f.field.Definition.Type, prefix+fieldName, 0)
+	}
+	g.printf("\treturn nil\n}\n\n")
+}
+
+// unmarshalInterface generates the code that decodes the raw json of a field with an interface or
+// union type, or a list of them, into the Go interface.
+func (g *generator) unmarshalInterface(dst, src string, typ *ast.Type, ifaceName string, depth int) {
+	indent := strings.Repeat("\t", 2*depth+1)
+	if typ.Elem == nil {
+		g.printf("%sif %s, err = unmarshal%s(%s); err != nil {\n%s\treturn err\n%s}\n", indent, dst, ifaceName, src, indent, indent)
+		return
+	}
+	i := fmt.Sprintf("i%d", depth)
+	g.printf("%sif %s != nil {\n", indent, src)
+	g.printf("%s\t%s = make(%s, len(%s))\n", indent, dst, g.outputType(typ, ifaceName), src)
+	g.printf("%s\tfor %s := range %s {\n", indent, i, src)
+	g.unmarshalInterface(dst+"["+i+"]", src+"["+i+"]", typ.Elem, ifaceName, depth+1)
+	g.printf("%s\t}\n%s}\n", indent, indent)
+}
@@ -0,0 +1,40 @@
+package gqlclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Codec encodes GraphQL requests and decodes GraphQL responses. It can be used to replace
+// encoding/json with a faster, compatible implementation.
+//  NewClient(endpoint, WithCodec(customCodec))
+type Codec interface {
+	// Marshal returns the json encoding of v.
+	Marshal(v interface{}) ([]byte, error)
+	// NewDecoder returns a Decoder that reads json values from r.
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Decoder reads and decodes json values from an input stream.
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// JSONCodec is the default Codec, which uses the encoding/json package.
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+// Marshal returns the json encoding of v followed by a newline, like the output of json.Encoder.
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (jsonCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
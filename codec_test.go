@@ -0,0 +1,163 @@
+package gqlclient_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteCodec struct {
+	suite.Suite
+}
+
+func TestSuiteCodec(t *testing.T) {
+	s := SuiteCodec{}
+	suite.Run(t, &s)
+}
+
+// indentCodec is a Codec that encodes indented json and decodes numbers as json.Number, and counts
+// its calls.
+type indentCodec struct {
+	marshals int
+	decoders int
+}
+
+func (c *indentCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals++
+	return json.MarshalIndent(v, "", "  ")
+}
+
+func (c *indentCodec) NewDecoder(r io.Reader) gql.Decoder {
+	c.decoders++
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	return dec
+}
+
+// client returns a client with the Codec and request builder, and the bodies of its requests.
+func (s *SuiteCodec) client(codec gql.Codec, builder gql.RequestBuilder) (*gql.Client, *[]*http.Request) {
+	var requests []*http.Request
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) *http.Response {
+			requests = append(requests, req)
+			return &http.Response{
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data": {"count": 12}}`)),
+				StatusCode: http.StatusOK,
+			}
+		}, nil)
+	return gql.NewClient("test", gql.WithHTTPClient(httpClient), gql.WithCodec(codec), gql.WithRequestBuilder(builder)), &requests
+}
+
+func (s *SuiteCodec) TestCustomCodec() {
+	codec := &indentCodec{}
+	c, requests := s.client(codec, gql.JSONRequestBuilder)
+
+	var resp struct {
+		Count interface{}
+	}
+	s.Require().NoError(c.Do(gql.NewRequest(`{ count }`, gql.WithVar("key", "value")), &resp))
+	s.Equal(1, codec.marshals)
+	s.Equal(1, codec.decoders)
+
+	// The body is encoded with Marshal, and the response is decoded with the Decoder of the Codec.
+	body, err := ioutil.ReadAll((*requests)[0].Body)
+	s.Require().NoError(err)
+	s.Equal("{\n  \"query\": \"{ count }\",\n  \"variables\": {\n    \"key\": \"value\"\n  }\n}", string(body))
+	s.Equal(json.Number("12"), resp.Count)
+}
+
+func (s *SuiteCodec) TestCustomCodecMultipart() {
+	codec := &indentCodec{}
+	c, requests := s.client(codec, gql.MultipartRequestBuilder)
+
+	s.Require().NoError(c.Do(gql.NewRequest(`{ count }`, gql.WithVar("key", "value")), nil))
+	s.Equal(1, codec.marshals)
+	s.Equal(1, codec.decoders)
+	s.Equal("{\n  \"key\": \"value\"\n}", (*requests)[0].PostFormValue("variables"))
+}
+
+var benchRequest = gql.NewRequest(`
+	query ($id: ID!, $first: Int!) {
+		user(id: $id) {
+			name
+			friends(first: $first) {
+				name
+			}
+		}
+	}`,
+	gql.WithVar("id", "1234567890"),
+	gql.WithVar("first", 10),
+)
+
+var benchResponse = []byte(`{"data":{"user":{"name":"John","friends":[
+	{"name":"Alice"},{"name":"Bob"},{"name":"Carol"},{"name":"Dave"},{"name":"Eve"},
+	{"name":"Frank"},{"name":"Grace"},{"name":"Heidi"},{"name":"Ivan"},{"name":"Judy"}
+]}}}`)
+
+type benchData struct {
+	Data struct {
+		User struct {
+			Name    string
+			Friends []struct {
+				Name string
+			}
+		}
+	}
+}
+
+// BenchmarkCodec runs the encode and decode benchmarks against the given Codec. Add a call for
+// an alternative Codec implementation to compare it with JSONCodec and plain encoding/json.
+func BenchmarkCodec(b *testing.B) {
+	b.Run("encoding/json", func(b *testing.B) {
+		benchmarkEncode(b, json.Marshal)
+		benchmarkDecode(b, func(r io.Reader, v interface{}) error {
+			return json.NewDecoder(r).Decode(v)
+		})
+	})
+	b.Run("JSONCodec", func(b *testing.B) {
+		benchmarkCodec(b, gql.JSONCodec)
+	})
+}
+
+func benchmarkCodec(b *testing.B, codec gql.Codec) {
+	benchmarkEncode(b, codec.Marshal)
+	benchmarkDecode(b, func(r io.Reader, v interface{}) error {
+		return codec.NewDecoder(r).Decode(v)
+	})
+}
+
+func benchmarkEncode(b *testing.B, marshal func(v interface{}) ([]byte, error)) {
+	b.Run("Encode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := marshal(benchRequest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func benchmarkDecode(b *testing.B, decode func(r io.Reader, v interface{}) error) {
+	b.Run("Decode", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(benchResponse)))
+		for i := 0; i < b.N; i++ {
+			var resp benchData
+			if err := decode(bytes.NewReader(benchResponse), &resp); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
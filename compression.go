@@ -0,0 +1,180 @@
+package gqlclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Encoding compresses and decompresses http bodies for a content coding like gzip. The Client
+// registers GzipEncoding and DeflateEncoding by default, register other Encodings using
+// WithEncoding.
+type Encoding interface {
+	// NewReader returns a reader that decompresses the data read from r.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+	// NewWriter returns a writer that compresses the data written to w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+}
+
+// GzipEncoding is the Encoding for the "gzip" content coding.
+var GzipEncoding Encoding = gzipEncoding{}
+
+// DeflateEncoding is the Encoding for the "deflate" content coding, which uses the zlib format.
+var DeflateEncoding Encoding = deflateEncoding{}
+
+// ErrUnsupportedEncoding is used when a response has a content coding that is not registered with
+// the Client.
+var ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+
+type gzipEncoding struct{}
+
+func (gzipEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+func (gzipEncoding) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+type deflateEncoding struct{}
+
+func (deflateEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+func (deflateEncoding) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(w), nil
+}
+
+// encodings is a registry of Encodings by content coding name, which keeps the registration order.
+type encodings struct {
+	names  []string
+	byName map[string]Encoding
+}
+
+// set registers the Encoding for the content coding name.
+func (e *encodings) set(name string, enc Encoding) {
+	name = strings.ToLower(name)
+	if e.byName == nil {
+		e.byName = make(map[string]Encoding)
+	}
+	if _, ok := e.byName[name]; !ok {
+		e.names = append(e.names, name)
+	}
+	e.byName[name] = enc
+}
+
+// acceptEncoding returns the value for the Accept-Encoding header.
+func (e *encodings) acceptEncoding() string {
+	return strings.Join(e.names, ", ")
+}
+
+// decompress replaces the body of the http response with a reader that decodes the content codings
+// in the Content-Encoding header.
+func (e *encodings) decompress(httpResp *http.Response) error {
+	contentEncoding := httpResp.Header.Get("Content-Encoding")
+	if contentEncoding == "" {
+		return nil
+	}
+
+	// Content codings are listed in the order in which they were applied, so undo them in reverse.
+	body := &multiCloser{ReadCloser: httpResp.Body}
+	codings := strings.Split(contentEncoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		name := strings.ToLower(strings.TrimSpace(codings[i]))
+		if name == "identity" || name == "" {
+			continue
+		}
+		enc, ok := e.byName[name]
+		if !ok {
+			_ = body.Close()
+			return fmt.Errorf("%w: %s", ErrUnsupportedEncoding, name)
+		}
+		r, err := enc.NewReader(body)
+		if err != nil {
+			_ = body.Close()
+			return fmt.Errorf("decompress %s: %w", name, err)
+		}
+		body = &multiCloser{ReadCloser: r, next: body}
+	}
+
+	httpResp.Body = body
+	httpResp.Header.Del("Content-Encoding")
+	httpResp.Header.Del("Content-Length")
+	httpResp.ContentLength = -1
+	return nil
+}
+
+// multiCloser is a ReadCloser that also closes the ReadCloser it reads from.
+type multiCloser struct {
+	io.ReadCloser
+	next io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	err := m.ReadCloser.Close()
+	if m.next != nil {
+		if nerr := m.next.Close(); err == nil {
+			err = nerr
+		}
+	}
+	return err
+}
+
+// requestCompression configures the compression of request bodies.
+type requestCompression struct {
+	name      string
+	encoding  Encoding
+	threshold int
+}
+
+// compressBody compresses the request body if it is at least as large as the threshold of the
+// request compression. It returns the content coding that was applied, if any.
+func (r *Request) compressBody(body []byte) ([]byte, string, error) {
+	c := r.compression
+	if c == nil || len(body) < c.threshold {
+		return body, "", nil
+	}
+
+	var compressed bytes.Buffer
+	w, err := c.encoding.NewWriter(&compressed)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return compressed.Bytes(), c.name, nil
+}
+
+// WithEncoding registers an Encoding for a content coding with the Client, in addition to gzip and
+// deflate which are registered by default. The Client advertises the registered content codings in
+// the Accept-Encoding header and decompresses responses itself, also with custom transports.
+// Registering an Encoding for gzip or deflate replaces the default one.
+//  NewClient(endpoint, WithEncoding("br", brotliEncoding))
+func WithEncoding(name string, enc Encoding) ClientOption {
+	return func(client *Client) {
+		client.encodings.set(name, enc)
+	}
+}
+
+// WithRequestCompression compresses request bodies of at least threshold bytes with the Encoding
+// of the content coding. The request builders set the Content-Encoding header accordingly.
+//  NewClient(endpoint, WithRequestCompression("gzip", GzipEncoding, 1024))
+func WithRequestCompression(name string, enc Encoding, threshold int) ClientOption {
+	return func(client *Client) {
+		client.compression = &requestCompression{
+			name:      strings.ToLower(name),
+			encoding:  enc,
+			threshold: threshold,
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package gqlclient_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteCompression struct {
+	suite.Suite
+}
+
+func TestSuiteCompression(t *testing.T) {
+	s := SuiteCompression{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteCompression) gzip(data string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	s.Require().NoError(err)
+	s.Require().NoError(w.Close())
+	return buf.Bytes()
+}
+
+func (s *SuiteCompression) TestDecompressResponse() {
+	var resp struct {
+		Value string
+	}
+
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Run(func(args mock.Arguments) {
+			r := args.Get(0).(*http.Request)
+			s.Equal("gzip, deflate", r.Header.Get("Accept-Encoding"))
+		}).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(bytes.NewReader(s.gzip(`{"data": {"value": "some data"}}`))),
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+		}, nil)
+
+	// gzip and deflate are registered by default.
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient))
+	err := c.Do(gql.NewRequest(""), &resp)
+	httpClient.AssertExpectations(s.T())
+	s.NoError(err)
+	s.Equal("some data", resp.Value)
+}
+
+func (s *SuiteCompression) TestUnsupportedEncoding() {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Encoding": []string{"br"}},
+		}, nil)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient))
+	err := c.Do(gql.NewRequest(""), nil)
+	s.ErrorIs(err, gql.ErrUnsupportedEncoding)
+}
+
+func (s *SuiteCompression) TestAdditionalEncoding() {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Run(func(args mock.Arguments) {
+			r := args.Get(0).(*http.Request)
+			s.Equal("gzip, deflate, x-gzip", r.Header.Get("Accept-Encoding"))
+		}).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(bytes.NewReader(s.gzip(`{"data": {}}`))),
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Encoding": []string{"x-gzip"}},
+		}, nil)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient),
+		gql.WithEncoding("x-gzip", gql.GzipEncoding))
+	s.NoError(c.Do(gql.NewRequest(""), nil))
+	httpClient.AssertExpectations(s.T())
+}
+
+func (s *SuiteCompression) TestCompressRequest() {
+	query := "query { " + string(bytes.Repeat([]byte("field "), 100)) + "}"
+
+	builders := map[string]gql.RequestBuilder{
+		"json":      gql.JSONRequestBuilder,
+		"multipart": gql.MultipartRequestBuilder,
+	}
+	for name, builder := range builders {
+		s.Run(name, func() {
+			httpClient := new(mocks.HTTPClient)
+			httpClient.
+				On("Do", mock.AnythingOfType("*http.Request")).
+				Run(func(args mock.Arguments) {
+					r := args.Get(0).(*http.Request)
+					s.Equal("gzip", r.Header.Get("Content-Encoding"))
+					zr, err := gzip.NewReader(r.Body)
+					s.Require().NoError(err)
+					body, err := ioutil.ReadAll(zr)
+					s.Require().NoError(err)
+					s.Contains(string(body), query)
+				}).
+				Return(&http.Response{
+					Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+					StatusCode: http.StatusOK,
+				}, nil)
+
+			c := gql.NewClient("test", gql.WithHTTPClient(httpClient),
+				gql.WithRequestBuilder(builder),
+				gql.WithRequestCompression("gzip", gql.GzipEncoding, 256))
+			err := c.Do(gql.NewRequest(query), nil)
+			httpClient.AssertExpectations(s.T())
+			s.NoError(err)
+		})
+	}
+}
+
+func (s *SuiteCompression) TestCompressRequestBelowThreshold() {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Run(func(args mock.Arguments) {
+			r := args.Get(0).(*http.Request)
+			s.Empty(r.Header.Get("Content-Encoding"))
+		}).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(bytes.NewReader([]byte("{}"))),
+			StatusCode: http.StatusOK,
+		}, nil)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient),
+		gql.WithRequestCompression("gzip", gql.GzipEncoding, 256))
+	err := c.Do(gql.NewRequest("query {}"), nil)
+	httpClient.AssertExpectations(s.T())
+	s.NoError(err)
+}
@@ -0,0 +1,188 @@
+package gqlclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DecodeOptions control how the data of a GraphQL response is decoded into the response object.
+type DecodeOptions struct {
+	// UseNumber decodes numbers into an interface{} as a json.Number instead of as a float64.
+	UseNumber bool
+	// DisallowUnknownFields returns an error when the data contains fields that do not match any
+	// field in the response object.
+	DisallowUnknownFields bool
+	// Strict returns a *MissingFieldsError when fields of the response object are missing from the
+	// data. Fields tagged with omitempty are allowed to be missing.
+	Strict bool
+}
+
+// ErrDecoderOption is used when the Decoder of the Codec does not support a DecodeOptions field.
+var ErrDecoderOption = errors.New("decoder does not support option")
+
+// newDecoder creates a Decoder with the options applied. DisallowUnknownFields is only applied
+// when data is true, since the GraphQL response itself may contain fields like extensions.
+func (o DecodeOptions) newDecoder(codec Codec, r io.Reader, data bool) (Decoder, error) {
+	dec := codec.NewDecoder(r)
+	if o.UseNumber {
+		d, ok := dec.(interface{ UseNumber() })
+		if !ok {
+			return nil, fmt.Errorf("%w: UseNumber", ErrDecoderOption)
+		}
+		d.UseNumber()
+	}
+	if o.DisallowUnknownFields && data {
+		d, ok := dec.(interface{ DisallowUnknownFields() })
+		if !ok {
+			return nil, fmt.Errorf("%w: DisallowUnknownFields", ErrDecoderOption)
+		}
+		d.DisallowUnknownFields()
+	}
+	return dec, nil
+}
+
+// decodeData decodes the raw data of a GraphQL response into v.
+func (o DecodeOptions) decodeData(codec Codec, data json.RawMessage, v interface{}) error {
+	dec, err := o.newDecoder(codec, bytes.NewReader(data), true)
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("decode data: %w", err)
+	}
+	if !o.Strict {
+		return nil
+	}
+
+	// Decode the data again into a generic value to find out which fields are present.
+	var raw interface{}
+	if err := codec.NewDecoder(bytes.NewReader(data)).Decode(&raw); err != nil {
+		return fmt.Errorf("decode data: %w", err)
+	}
+	if missing := missingFields("", raw, reflect.TypeOf(v)); len(missing) > 0 {
+		return &MissingFieldsError{Paths: missing}
+	}
+	return nil
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// missingFields returns the paths of the fields of type t that are missing from the decoded json
+// value v.
+func missingFields(path string, v interface{}, t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		if t.Implements(unmarshalerType) {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if v == nil || reflect.PtrTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+
+	var missing []string
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for _, f := range jsonFields(t) {
+			value, ok := lookupField(object, f.name)
+			if !ok {
+				if !f.omitEmpty {
+					missing = append(missing, joinPath(path, f.name))
+				}
+				continue
+			}
+			missing = append(missing, missingFields(joinPath(path, f.name), value, f.typ)...)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, value := range list {
+			missing = append(missing, missingFields(path+"["+strconv.Itoa(i)+"]", value, t.Elem())...)
+		}
+	}
+	return missing
+}
+
+// jsonField is a struct field as seen by encoding/json.
+type jsonField struct {
+	name      string
+	index     []int
+	typ       reflect.Type
+	omitEmpty bool
+}
+
+// jsonFields returns the fields of struct type t that encoding/json decodes into, including the
+// fields of embedded structs.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx != -1 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			for _, f := range jsonFields(ft) {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			// Skip unexported fields.
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, jsonField{
+			name:      name,
+			index:     []int{i},
+			typ:       sf.Type,
+			omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+		})
+	}
+	return fields
+}
+
+// lookupField finds the value of a field in a json object, preferring an exact match but falling
+// back to a case-insensitive match like encoding/json does.
+func lookupField(object map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,120 @@
+package gqlclient_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteDecodeOptions struct {
+	suite.Suite
+}
+
+func TestSuiteDecodeOptions(t *testing.T) {
+	s := SuiteDecodeOptions{}
+	suite.Run(t, &s)
+}
+
+// client returns a Client that responds with the given body to every request.
+func (s *SuiteDecodeOptions) client(body string, opts ...gql.ClientOption) *gql.Client {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			StatusCode: http.StatusOK,
+		}, nil)
+	return gql.NewClient("test", append([]gql.ClientOption{gql.WithHTTPClient(httpClient)}, opts...)...)
+}
+
+func (s *SuiteDecodeOptions) TestUseNumber() {
+	var resp struct {
+		ID interface{}
+	}
+
+	c := s.client(`{"data": {"id": 12345678901234567890}}`,
+		gql.WithDefaultDecodeOptions(gql.DecodeOptions{UseNumber: true}))
+	err := c.Do(gql.NewRequest(""), &resp)
+	s.NoError(err)
+	s.Equal(json.Number("12345678901234567890"), resp.ID)
+}
+
+func (s *SuiteDecodeOptions) TestDisallowUnknownFields() {
+	var resp struct {
+		Name string
+	}
+
+	c := s.client(`{"data": {"fullName": "John"}, "extensions": {"cost": 1}}`)
+	err := c.Do(gql.NewRequest("", gql.WithDecodeOptions(gql.DecodeOptions{DisallowUnknownFields: true})), &resp)
+	s.Error(err)
+	s.Contains(err.Error(), "fullName")
+}
+
+func (s *SuiteDecodeOptions) TestDisallowUnknownFieldsIgnoresExtensions() {
+	var resp struct {
+		Name string
+	}
+
+	c := s.client(`{"data": {"name": "John"}, "extensions": {"cost": 1}}`,
+		gql.WithDefaultDecodeOptions(gql.DecodeOptions{DisallowUnknownFields: true}))
+	err := c.Do(gql.NewRequest(""), &resp)
+	s.NoError(err)
+	s.Equal("John", resp.Name)
+}
+
+func (s *SuiteDecodeOptions) TestRequestOverridesClient() {
+	var resp struct {
+		Name string
+	}
+
+	c := s.client(`{"data": {"fullName": "John"}}`,
+		gql.WithDefaultDecodeOptions(gql.DecodeOptions{DisallowUnknownFields: true}))
+	err := c.Do(gql.NewRequest("", gql.WithDecodeOptions(gql.DecodeOptions{})), &resp)
+	s.NoError(err)
+}
+
+func (s *SuiteDecodeOptions) TestStrict() {
+	type Friend struct {
+		Name  string
+		Email string `json:"email,omitempty"`
+	}
+	var resp struct {
+		User struct {
+			Name    string
+			Age     *int
+			Friends []Friend
+		}
+	}
+
+	c := s.client(`{"data": {"user": {"age": null, "friends": [{"name": "Alice"}, {}]}}}`,
+		gql.WithDefaultDecodeOptions(gql.DecodeOptions{Strict: true}))
+	err := c.Do(gql.NewRequest(""), &resp)
+
+	var merr *gql.MissingFieldsError
+	s.Require().ErrorAs(err, &merr)
+	s.Equal([]string{"User.Name", "User.Friends[1].Name"}, merr.Paths)
+	s.Equal("Alice", resp.User.Friends[0].Name)
+}
+
+func (s *SuiteDecodeOptions) TestStrictGQLErrorsFirst() {
+	var resp struct {
+		User struct {
+			Name string
+		}
+	}
+
+	c := s.client(`{"data": {"user": null}, "errors": [{"message": "not found"}]}`,
+		gql.WithDefaultDecodeOptions(gql.DecodeOptions{Strict: true}))
+	err := c.Do(gql.NewRequest(""), &resp)
+
+	var gqlerrs gql.ErrorList
+	s.ErrorAs(err, &gqlerrs)
+}
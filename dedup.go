@@ -0,0 +1,127 @@
+package gqlclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// WithDeduplication collapses identical queries that are executed concurrently with Do into a single
+// request. Queries are identical when they have the same query, variables and headers. The response
+// is decoded into the response object of every caller separately. A caller that stops waiting
+// because its Context is done gets the error of its Context. The request keeps the values of the
+// Context of the first caller, but is not cancelled with it, and times out after a minute.
+// Mutations are never collapsed.
+//  NewClient(endpoint, WithDeduplication())
+func WithDeduplication() ClientOption {
+	return func(client *Client) {
+		client.inflight = &inflightRequests{calls: make(map[string]*inflightCall)}
+	}
+}
+
+// inflightRequests tracks the queries that are being sent.
+type inflightRequests struct {
+	mu    sync.Mutex
+	calls map[string]*inflightCall
+}
+
+// inflightCall is a request that is being sent, and its result when done is closed.
+type inflightCall struct {
+	done chan struct{}
+	raw  *RawResponse
+	err  error
+}
+
+// key returns the key of the Request with the headers, or false when it is not a query that can be
+// collapsed.
+func (f *inflightRequests) key(req *Request, q *cachedQuery, headers []http.Header, codec Codec) (string, bool) {
+	if op := q.operation(); op == nil || op.Operation != ast.Query {
+		return "", false
+	}
+
+	variables, err := codec.Marshal(req.Variables)
+	if err != nil {
+		return "", false
+	}
+	var key bytes.Buffer
+	fmt.Fprintf(&key, "%d:%s%d:%s", len(req.Query), req.Query, len(variables), variables)
+	for _, header := range headers {
+		fmt.Fprintf(&key, "%d:", len(header))
+		for _, name := range sortedKeys(header) {
+			fmt.Fprintf(&key, "%d:%s%d:", len(name), name, len(header[name]))
+			for _, value := range header[name] {
+				fmt.Fprintf(&key, "%d:%s", len(value), value)
+			}
+		}
+	}
+	return key.String(), true
+}
+
+// do calls fn in the background for the first caller with the key, and shares its result with the
+// callers that arrive before it is done. Every caller stops waiting when its own Context is done.
+func (f *inflightRequests) do(ctx context.Context, key string, fn func() (*RawResponse, error)) (*RawResponse, error) {
+	f.mu.Lock()
+	call, ok := f.calls[key]
+	if !ok {
+		call = &inflightCall{done: make(chan struct{})}
+		f.calls[key] = call
+		go func() {
+			call.raw, call.err = fn()
+			f.mu.Lock()
+			delete(f.calls, key)
+			f.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	f.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.raw, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// doRaw executes the Request with DoRaw, sharing the response with identical concurrent queries
+// when deduplication is enabled.
+func (c *Client) doRaw(req *Request) (*RawResponse, error) {
+	if c.inflight != nil {
+		// The header functions are called once, for the key and for the request that is sent.
+		header := c.header(req)
+		headers := []http.Header{header.defaults, header.request}
+		if key, ok := c.inflight.key(req, c.queries.get(req.Query), headers, c.codec); ok {
+			return c.inflight.do(req.ctx, key, func() (*RawResponse, error) {
+				ctx, cancel := context.WithTimeout(detachedContext{req.ctx}, backgroundTimeout)
+				defer cancel()
+				shared := *req
+				shared.ctx = ctx
+				return c.sendRaw(&shared, header)
+			})
+		}
+		return c.sendRaw(req, header)
+	}
+	return c.DoRaw(req)
+}
+
+// decodeRaw decodes the data of a raw response into resp, and returns its GraphQL errors like Do.
+func (c *Client) decodeRaw(req *Request, raw *RawResponse, resp interface{}) error {
+	var errs ErrorList
+	if len(raw.Errors) > 0 {
+		if err := c.codec.NewDecoder(bytes.NewReader(raw.Errors)).Decode(&errs); err != nil {
+			return ErrBadResponse
+		}
+	}
+	var dataErr error
+	if resp != nil && len(raw.Data) > 0 {
+		dataErr = c.decodeOptionsFor(req).decodeData(c.codec, raw.Data, resp)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return dataErr
+}
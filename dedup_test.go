@@ -0,0 +1,219 @@
+package gqlclient_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteDeduplication struct {
+	suite.Suite
+	httpClient *mocks.HTTPClient
+	release    chan struct{}
+	// entered receives a value when a request is sent, and waiting when a caller waits for the
+	// request of a query.
+	entered chan struct{}
+	waiting chan struct{}
+}
+
+func TestSuiteDeduplication(t *testing.T) {
+	s := SuiteDeduplication{}
+	suite.Run(t, &s)
+}
+
+// client returns a client with deduplication, whose requests wait until release is closed.
+func (s *SuiteDeduplication) client(opts ...gql.ClientOption) *gql.Client {
+	s.release = make(chan struct{})
+	s.entered = make(chan struct{}, 16)
+	s.waiting = make(chan struct{}, 16)
+	s.httpClient = new(mocks.HTTPClient)
+	s.httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) *http.Response {
+			s.entered <- struct{}{}
+			<-s.release
+			return &http.Response{
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data": {"user": {"name": "Bob"}}}`)),
+				StatusCode: http.StatusOK,
+			}
+		}, nil)
+	opts = append(opts, gql.WithHTTPClient(s.httpClient), gql.WithDeduplication())
+	return gql.NewClient("test", opts...)
+}
+
+// waitingContext is a Context that signals when a caller waits for it to be done, which callers
+// of queries do once their request is sent or shared.
+type waitingContext struct {
+	context.Context
+	once    sync.Once
+	waiting chan<- struct{}
+}
+
+func (c *waitingContext) Done() <-chan struct{} {
+	c.once.Do(func() {
+		c.waiting <- struct{}{}
+	})
+	return c.Context.Done()
+}
+
+// waitable returns a Context with the values of ctx that signals waiting.
+func (s *SuiteDeduplication) waitable(ctx context.Context) context.Context {
+	return &waitingContext{Context: ctx, waiting: s.waiting}
+}
+
+// request returns a new Request with a Context that signals waiting.
+func (s *SuiteDeduplication) request(query string, opts ...gql.RequestOption) *gql.Request {
+	opts = append([]gql.RequestOption{gql.WithContext(s.waitable(context.Background()))}, opts...)
+	return gql.NewRequest(query, opts...)
+}
+
+// doConcurrently executes the requests concurrently, and releases the responses once every query is
+// waiting for its request. Mutations are not collapsed, so they are not waited for.
+func (s *SuiteDeduplication) doConcurrently(c *gql.Client, reqs ...*gql.Request) []error {
+	errs := make([]error, len(reqs))
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Add(1)
+		go func(i int, req *gql.Request) {
+			defer wg.Done()
+			var resp struct {
+				User struct {
+					Name string
+				}
+			}
+			errs[i] = c.Do(req, &resp)
+			if errs[i] == nil {
+				s.Equal("Bob", resp.User.Name)
+			}
+		}(i, req)
+	}
+	for _, req := range reqs {
+		if !strings.HasPrefix(req.Query, "mutation") {
+			<-s.waiting
+		}
+	}
+	close(s.release)
+	wg.Wait()
+	return errs
+}
+
+func (s *SuiteDeduplication) TestIdenticalQueries() {
+	c := s.client()
+	query := `query ($id: ID!) { user(id: $id) { name } }`
+	reqs := make([]*gql.Request, 10)
+	for i := range reqs {
+		reqs[i] = s.request(query, gql.WithVar("id", "1"))
+	}
+	for _, err := range s.doConcurrently(c, reqs...) {
+		s.NoError(err)
+	}
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 1)
+
+	// Later queries are sent again.
+	s.NoError(c.Do(reqs[0], nil))
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 2)
+}
+
+func (s *SuiteDeduplication) TestDifferentRequests() {
+	c := s.client()
+	query := `query ($id: ID!) { user(id: $id) { name } }`
+	mutation := `mutation { rename(id: 1, name: "Bob") { name } }`
+	errs := s.doConcurrently(c,
+		s.request(query, gql.WithVar("id", "1")),
+		s.request(query, gql.WithVar("id", "2")),
+		s.request(query, gql.WithVar("id", "1"), gql.WithHeader("Authorization", "a")),
+		s.request(mutation),
+		s.request(mutation),
+	)
+	for _, err := range errs {
+		s.NoError(err)
+	}
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 5)
+}
+
+func (s *SuiteDeduplication) TestHeaderFuncs() {
+	// Queries with different headers from the Context are not collapsed.
+	type tenantKey struct{}
+	c := s.client(gql.WithDefaultHeaderFunc(gql.ContextHeader("X-Tenant-Id", tenantKey{})))
+	query := `{ user(id: 1) { name } }`
+	errs := s.doConcurrently(c,
+		s.request(query, gql.WithContext(s.waitable(context.WithValue(context.Background(), tenantKey{}, "a")))),
+		s.request(query, gql.WithContext(s.waitable(context.WithValue(context.Background(), tenantKey{}, "b")))),
+		s.request(query, gql.WithContext(s.waitable(context.WithValue(context.Background(), tenantKey{}, "b")))),
+		s.request(query, gql.WithHeaderValues("Accept-Language", "nl", "en")),
+		s.request(query, gql.WithHeaderValues("Accept-Language", "nl")),
+	)
+	for _, err := range errs {
+		s.NoError(err)
+	}
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 4)
+
+	// The header functions are called once for the key and the request.
+	var calls int
+	c = s.client(gql.WithDefaultHeaderFunc(func(context.Context, *gql.Request) http.Header {
+		calls++
+		return http.Header{"X-Call": {strconv.Itoa(calls)}}
+	}))
+	close(s.release)
+	s.NoError(c.Do(gql.NewRequest(query), nil))
+	s.Equal(1, calls)
+	s.Equal("1", s.httpClient.Calls[0].Arguments.Get(0).(*http.Request).Header.Get("X-Call"))
+}
+
+func (s *SuiteDeduplication) TestCancelledCaller() {
+	c := s.client()
+	query := `{ user(id: 1) { name } }`
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.NoError(c.Do(gql.NewRequest(query), nil))
+	}()
+	<-s.entered
+
+	// The caller stops waiting, while the request continues for the first caller.
+	err := c.Do(gql.NewRequest(query, gql.WithContext(ctx)), nil)
+	s.Equal(context.Canceled, err)
+	close(s.release)
+	wg.Wait()
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 1)
+}
+
+func (s *SuiteDeduplication) TestCancelledFirstCaller() {
+	c := s.client()
+	query := `{ user(id: 1) { name } }`
+	ctx, cancel := context.WithCancel(context.Background())
+
+	first := make(chan error)
+	go func() {
+		first <- c.Do(s.request(query, gql.WithContext(s.waitable(ctx))), nil)
+	}()
+	<-s.waiting
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.NoError(c.Do(s.request(query), nil))
+	}()
+	<-s.waiting
+
+	// The first caller stops waiting, without cancelling the request that the other caller shares.
+	cancel()
+	s.Equal(context.Canceled, <-first)
+	close(s.release)
+	wg.Wait()
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 1)
+}
@@ -0,0 +1,152 @@
+package gqlclient
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// ErrUnknownOperation is returned by Documents when an operation with the requested name is not
+// loaded.
+var ErrUnknownOperation = errors.New("unknown operation")
+
+// Documents is a registry of named operations that are loaded from .graphql files. The document of
+// an operation contains the fragments that it uses, also when they are defined in another file.
+type Documents struct {
+	queries map[string]string
+}
+
+// LoadDocuments loads the operations and fragments in the .graphql and .gql files of a file system,
+// including its subdirectories. Operations must be named, and the names of operations and fragments
+// must be unique across all files.
+//  //go:embed graphql
+//  var files embed.FS
+//
+//  docs, err := gqlclient.LoadDocuments(files)
+//  req, err := docs.Request("GetUser", gqlclient.WithVar("id", id))
+func LoadDocuments(fsys fs.FS) (*Documents, error) {
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := path.Ext(name)
+		if !entry.IsDir() && (ext == ".graphql" || ext == ".gql") {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var operations ast.OperationList
+	var fragments ast.FragmentDefinitionList
+	for _, file := range files {
+		input, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		doc, gerr := parser.ParseQuery(&ast.Source{Name: file, Input: string(input)})
+		if gerr != nil {
+			return nil, gerr
+		}
+		for _, op := range doc.Operations {
+			if op.Name == "" {
+				return nil, fmt.Errorf("%s: operations must be named", position(op.Position))
+			}
+			if other := operations.ForName(op.Name); other != nil {
+				return nil, fmt.Errorf("%s: operation %s is also defined at %s",
+					position(op.Position), op.Name, position(other.Position))
+			}
+			operations = append(operations, op)
+		}
+		for _, frag := range doc.Fragments {
+			if other := fragments.ForName(frag.Name); other != nil {
+				return nil, fmt.Errorf("%s: fragment %s is also defined at %s",
+					position(frag.Position), frag.Name, position(other.Position))
+			}
+			fragments = append(fragments, frag)
+		}
+	}
+
+	d := &Documents{queries: make(map[string]string, len(operations))}
+	for _, op := range operations {
+		used, err := usedFragments(op.SelectionSet, fragments, nil)
+		if err != nil {
+			return nil, fmt.Errorf("operation %s: %w", op.Name, err)
+		}
+		var buf bytes.Buffer
+		formatter.NewFormatter(&buf).FormatQueryDocument(&ast.QueryDocument{
+			Operations: ast.OperationList{op},
+			Fragments:  used,
+		})
+		d.queries[op.Name] = buf.String()
+	}
+	return d, nil
+}
+
+// usedFragments returns the fragments that are spread in the selection set, including the fragments
+// that are spread in those fragments.
+func usedFragments(selections ast.SelectionSet, fragments, used ast.FragmentDefinitionList) (ast.FragmentDefinitionList, error) {
+	var err error
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			used, err = usedFragments(selection.SelectionSet, fragments, used)
+		case *ast.InlineFragment:
+			used, err = usedFragments(selection.SelectionSet, fragments, used)
+		case *ast.FragmentSpread:
+			if used.ForName(selection.Name) != nil {
+				continue
+			}
+			def := fragments.ForName(selection.Name)
+			if def == nil {
+				return nil, fmt.Errorf("%s: fragment %s is not defined", position(selection.Position), selection.Name)
+			}
+			used, err = usedFragments(def.SelectionSet, fragments, append(used, def))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return used, nil
+}
+
+// position returns the file and line of a position in a document.
+func position(pos *ast.Position) string {
+	return fmt.Sprintf("%s:%d", pos.Src.Name, pos.Line)
+}
+
+// Names returns the sorted names of the operations.
+func (d *Documents) Names() []string {
+	names := make([]string, 0, len(d.queries))
+	for name := range d.queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Query returns the document of an operation, with the fragments that it uses.
+func (d *Documents) Query(name string) (string, bool) {
+	query, ok := d.queries[name]
+	return query, ok
+}
+
+// Request makes a new Request for an operation. The options are passed to NewRequest.
+//  req, err := docs.Request("GetUser", gqlclient.WithVar("id", id))
+func (d *Documents) Request(name string, opts ...RequestOption) (*Request, error) {
+	query, ok := d.queries[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrUnknownOperation, name)
+	}
+	return NewRequest(query, opts...), nil
+}
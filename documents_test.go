@@ -0,0 +1,104 @@
+package gqlclient_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2"
+
+	gql "github.com/weavedev/go-gqlclient"
+)
+
+type SuiteDocuments struct {
+	suite.Suite
+}
+
+func TestSuiteDocuments(t *testing.T) {
+	s := SuiteDocuments{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteDocuments) TestLoadDocuments() {
+	fsys := fstest.MapFS{
+		"user.graphql": {Data: []byte(`
+			# Get a user by id.
+			query GetUser($id: ID!) {
+				user(id: $id) { ...UserFields }
+			}
+			mutation Rename($id: ID!, $name: String!) {
+				rename(id: $id, name: $name) { id }
+			}`)},
+		"fragments/user.gql": {Data: []byte(`
+			fragment UserFields on User { id ...UserName }
+			fragment UserName on User { name }
+			fragment Unused on User { avatar }`)},
+		"README.md": {Data: []byte("not a document")},
+	}
+
+	docs, err := gql.LoadDocuments(fsys)
+	s.Require().NoError(err)
+	s.Equal([]string{"GetUser", "Rename"}, docs.Names())
+
+	query, ok := docs.Query("GetUser")
+	s.Require().True(ok)
+	s.Equal("query GetUser ($id: ID!) {\n\tuser(id: $id) {\n\t\t... UserFields\n\t}\n}\n"+
+		"fragment UserFields on User {\n\tid\n\t... UserName\n}\n"+
+		"fragment UserName on User {\n\tname\n}\n", query)
+	_, errs := gqlparser.LoadQuery(structQuerySchema, query)
+	s.Empty(errs)
+
+	req, err := docs.Request("Rename", gql.WithVar("id", "1"), gql.WithVar("name", "Bob"))
+	s.Require().NoError(err)
+	s.Equal("mutation Rename ($id: ID!, $name: String!) {\n\trename(id: $id, name: $name) {\n\t\tid\n\t}\n}\n", req.Query)
+	s.Equal(map[string]interface{}{"id": "1", "name": "Bob"}, req.Variables)
+
+	_, err = docs.Request("GetPost")
+	s.True(errors.Is(err, gql.ErrUnknownOperation))
+	s.EqualError(err, "unknown operation GetPost")
+	_, ok = docs.Query("GetPost")
+	s.False(ok)
+}
+
+func (s *SuiteDocuments) TestLoadDocumentsErrors() {
+	tests := map[string]fstest.MapFS{
+		"a.graphql:1: operations must be named": {
+			"a.graphql": {Data: []byte(`{ user(id: 1) { id } }`)},
+		},
+		"b.graphql:1: operation GetUser is also defined at a.graphql:1": {
+			"a.graphql": {Data: []byte(`query GetUser { user(id: 1) { id } }`)},
+			"b.graphql": {Data: []byte(`query GetUser { user(id: 2) { id } }`)},
+		},
+		"b.graphql:2: fragment UserFields is also defined at a.graphql:1": {
+			"a.graphql": {Data: []byte(`fragment UserFields on User { id }`)},
+			"b.graphql": {Data: []byte("\nfragment UserFields on User { name }")},
+		},
+		"operation GetUser: a.graphql:1: fragment UserFields is not defined": {
+			"a.graphql": {Data: []byte(`query GetUser { user(id: 1) { ...UserFields } }`)},
+		},
+		"a.graphql:1: Expected Name, found <EOF>": {
+			"a.graphql": {Data: []byte(`query GetUser { user(id: 1) { id }`)},
+		},
+	}
+	for expected, fsys := range tests {
+		_, err := gql.LoadDocuments(fsys)
+		s.EqualError(err, expected)
+	}
+}
+
+func (s *SuiteDocuments) TestRecursiveFragments() {
+	// Recursive fragments are invalid, but loading them must not loop forever.
+	fsys := fstest.MapFS{
+		"a.graphql": {Data: []byte(`
+			query GetUser { user(id: 1) { ...A } }
+			fragment A on User { friends { ...B } }
+			fragment B on User { friends { ...A } }`)},
+	}
+	docs, err := gql.LoadDocuments(fsys)
+	s.Require().NoError(err)
+	query, _ := docs.Query("GetUser")
+	s.Equal(1, strings.Count(query, "fragment A on User"))
+	s.Equal(1, strings.Count(query, "fragment B on User"))
+}
@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
 // HTTPError represents an error that occurred in the http transport layer and not in the GraphQL layer.
@@ -27,3 +30,30 @@ func NewHTTPError(statusCode int) *HTTPError {
 
 // ErrBadResponse is used when the response body cannot be parsed.
 var ErrBadResponse = errors.New("response was not GraphQL compliant")
+
+// MissingFieldsError is used in strict decoding mode when fields of the response object are
+// missing from the data of the response.
+type MissingFieldsError struct {
+	Paths []string
+}
+
+func (e *MissingFieldsError) Error() string {
+	return fmt.Sprintf("response data is missing fields: %s", strings.Join(e.Paths, ", "))
+}
+
+// ValidationError is used when the query of a Request is not valid for the schema of the Client.
+type ValidationError struct {
+	Errors gqlerror.List
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		if len(err.Locations) > 0 {
+			messages = append(messages, fmt.Sprintf("%d:%d: %s", err.Locations[0].Line, err.Locations[0].Column, err.Message))
+		} else {
+			messages = append(messages, err.Message)
+		}
+	}
+	return fmt.Sprintf("query validation: %s", strings.Join(messages, "; "))
+}
@@ -1,9 +1,10 @@
 module github.com/weavedev/go-gqlclient
 
-go 1.13
+go 1.16
 
 require (
 	github.com/stretchr/testify v1.7.0
 	github.com/vektah/gqlparser/v2 v2.1.0
 	github.com/vektra/mockery/v2 v2.7.4 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
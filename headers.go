@@ -0,0 +1,101 @@
+package gqlclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// HeaderFunc returns header entries for a Request, for example with values from its Context. The
+// function is called once every time the Request is executed, and the entries are used for the
+// key of the response cache and of deduplication as well as for the http requests that are sent.
+type HeaderFunc func(ctx context.Context, req *Request) http.Header
+
+// WithDefaultHeaderValues sets a default entry with multiple values in the header of every Request
+// sent with this client.
+//  NewClient(endpoint, WithDefaultHeaderValues("Accept-Language", "nl", "en"))
+func WithDefaultHeaderValues(key string, values ...string) ClientOption {
+	return func(client *Client) {
+		client.defaultHeaders[http.CanonicalHeaderKey(key)] = values
+	}
+}
+
+// WithDefaultHeaderFunc adds a function that returns default header entries for every Request sent
+// with this client, which replace the default headers with the same key. The headers of a Request
+// replace the entries of the function.
+//  NewClient(endpoint, WithDefaultHeaderFunc(ContextHeader("X-Tenant-Id", tenantKey{})))
+func WithDefaultHeaderFunc(fn HeaderFunc) ClientOption {
+	return func(client *Client) {
+		client.headerFuncs = append(client.headerFuncs, fn)
+	}
+}
+
+// ContextHeader returns a HeaderFunc that sets the header entry to the value of the Context for the
+// key, to propagate values like request or tenant ids. The value must be a string or a []string,
+// other values and empty strings are ignored.
+//  type requestIDKey struct{}
+//  ctx = context.WithValue(ctx, requestIDKey{}, "f3a1")
+//  NewRequest(query, WithContext(ctx), WithHeaderFunc(ContextHeader("X-Request-Id", requestIDKey{})))
+func ContextHeader(header string, key interface{}) HeaderFunc {
+	return func(ctx context.Context, _ *Request) http.Header {
+		switch value := ctx.Value(key).(type) {
+		case string:
+			if value != "" {
+				return http.Header{http.CanonicalHeaderKey(header): {value}}
+			}
+		case []string:
+			if len(value) > 0 {
+				return http.Header{http.CanonicalHeaderKey(header): value}
+			}
+		}
+		return nil
+	}
+}
+
+// requestHeader are the headers of a Request: the default headers of the Client and the headers of
+// the Request itself, which replace the defaults.
+type requestHeader struct {
+	defaults http.Header
+	request  http.Header
+}
+
+// header returns the headers of the Request, calling the header functions.
+func (c *Client) header(req *Request) requestHeader {
+	return requestHeader{defaults: c.defaultHeader(req), request: req.header()}
+}
+
+// values returns the values of the header entry, taken from the Request when it has the entry.
+func (h requestHeader) values(key string) []string {
+	if values, ok := h.request[http.CanonicalHeaderKey(key)]; ok {
+		return values
+	}
+	return h.defaults.Values(key)
+}
+
+// defaultHeader returns the default headers of the Client for the Request, including the entries
+// of the header functions.
+func (c *Client) defaultHeader(req *Request) http.Header {
+	header := c.defaultHeaders.Clone()
+	for _, fn := range c.headerFuncs {
+		setHeader(header, fn(req.ctx, req))
+	}
+	return header
+}
+
+// header returns the headers of the Request, including the entries of its header functions.
+func (r *Request) header() http.Header {
+	header := r.headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	for _, fn := range r.headerFuncs {
+		setHeader(header, fn(r.ctx, r))
+	}
+	return header
+}
+
+// setHeader replaces the entries of dst with the entries of src that have the same key.
+func setHeader(dst, src http.Header) {
+	for key, values := range src {
+		dst[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+}
@@ -0,0 +1,92 @@
+package gqlclient_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteHeaders struct {
+	suite.Suite
+	httpClient *mocks.HTTPClient
+	header     http.Header
+}
+
+func TestSuiteHeaders(t *testing.T) {
+	s := SuiteHeaders{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteHeaders) SetupTest() {
+	s.httpClient = new(mocks.HTTPClient)
+	s.httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) *http.Response {
+			s.header = req.Header
+			return &http.Response{
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data": {}}`)),
+				StatusCode: http.StatusOK,
+			}
+		}, nil)
+}
+
+type tenantKey struct{}
+
+func (s *SuiteHeaders) TestHeaders() {
+	c := gql.NewClient("test",
+		gql.WithHTTPClient(s.httpClient),
+		gql.WithDefaultHeader("X-Client", "a"),
+		gql.WithDefaultHeaderValues("accept-language", "nl", "en"),
+		gql.WithDefaultHeaderValues("X-Replaced", "default"),
+		gql.WithDefaultHeaderFunc(func(ctx context.Context, req *gql.Request) http.Header {
+			return http.Header{"X-Replaced": {"client func"}, "X-Client-Func": {"b"}}
+		}),
+		gql.WithDefaultHeaderFunc(gql.ContextHeader("X-Tenant-Id", tenantKey{})))
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "t1")
+	s.Require().NoError(c.Do(gql.NewRequest(`{ viewer { name } }`,
+		gql.WithContext(ctx),
+		gql.WithHeaderValues("x-forwarded-for", "1.1.1.1", "2.2.2.2"),
+		gql.WithHeaderFunc(func(ctx context.Context, req *gql.Request) http.Header {
+			return http.Header{"x-replaced": {"request func"}}
+		})), nil))
+
+	s.Equal([]string{"application/json; charset=utf-8"}, s.header["Accept"])
+	s.Equal([]string{"a"}, s.header["X-Client"])
+	s.Equal([]string{"nl", "en"}, s.header["Accept-Language"])
+	s.Equal([]string{"b"}, s.header["X-Client-Func"])
+	s.Equal([]string{"t1"}, s.header["X-Tenant-Id"])
+	s.Equal([]string{"1.1.1.1", "2.2.2.2"}, s.header["X-Forwarded-For"])
+	s.Equal([]string{"request func"}, s.header["X-Replaced"])
+
+	// The headers of the request replace the headers of the functions of the client.
+	s.Require().NoError(c.Do(gql.NewRequest(`{ viewer { name } }`, gql.WithHeader("X-Replaced", "request")), nil))
+	s.Equal([]string{"request"}, s.header["X-Replaced"])
+	s.NotContains(s.header, "X-Tenant-Id")
+}
+
+func (s *SuiteHeaders) TestContextHeader() {
+	fn := gql.ContextHeader("x-request-id", tenantKey{})
+	tests := []struct {
+		value interface{}
+		want  http.Header
+	}{
+		{value: "abc", want: http.Header{"X-Request-Id": {"abc"}}},
+		{value: []string{"a", "b"}, want: http.Header{"X-Request-Id": {"a", "b"}}},
+		{value: "", want: nil},
+		{value: 1, want: nil},
+		{value: nil, want: nil},
+	}
+	for _, test := range tests {
+		ctx := context.WithValue(context.Background(), tenantKey{}, test.value)
+		s.Equal(test.want, fn(ctx, gql.NewRequest(`{ viewer { name } }`)))
+	}
+}
@@ -0,0 +1,494 @@
+package gqlclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// IntrospectionResponse is the data of a response to an introspection query.
+type IntrospectionResponse struct {
+	Schema *IntrospectionSchema `json:"__schema"`
+}
+
+// IntrospectionSchema is the __Schema type of the GraphQL introspection system.
+type IntrospectionSchema struct {
+	Description      *string                   `json:"description,omitempty"`
+	QueryType        *IntrospectionTypeName    `json:"queryType"`
+	MutationType     *IntrospectionTypeName    `json:"mutationType"`
+	SubscriptionType *IntrospectionTypeName    `json:"subscriptionType"`
+	Types            []*IntrospectionType      `json:"types"`
+	Directives       []*IntrospectionDirective `json:"directives"`
+}
+
+// IntrospectionTypeName refers to a named type of the schema.
+type IntrospectionTypeName struct {
+	Name string `json:"name"`
+}
+
+// IntrospectionType is the __Type type of the GraphQL introspection system for named types.
+type IntrospectionType struct {
+	Kind           string                     `json:"kind"`
+	Name           string                     `json:"name"`
+	Description    *string                    `json:"description"`
+	SpecifiedByURL *string                    `json:"specifiedByURL,omitempty"`
+	Fields         []*IntrospectionField      `json:"fields"`
+	InputFields    []*IntrospectionInputValue `json:"inputFields"`
+	Interfaces     []*IntrospectionTypeRef    `json:"interfaces"`
+	EnumValues     []*IntrospectionEnumValue  `json:"enumValues"`
+	PossibleTypes  []*IntrospectionTypeRef    `json:"possibleTypes"`
+}
+
+// IntrospectionTypeRef is the __Type type of the GraphQL introspection system for type references,
+// which are either named types or LIST or NON_NULL wrappers of another type reference.
+type IntrospectionTypeRef struct {
+	Kind   string                `json:"kind"`
+	Name   *string               `json:"name"`
+	OfType *IntrospectionTypeRef `json:"ofType"`
+}
+
+// IntrospectionField is the __Field type of the GraphQL introspection system.
+type IntrospectionField struct {
+	Name              string                     `json:"name"`
+	Description       *string                    `json:"description"`
+	Args              []*IntrospectionInputValue `json:"args"`
+	Type              *IntrospectionTypeRef      `json:"type"`
+	IsDeprecated      bool                       `json:"isDeprecated"`
+	DeprecationReason *string                    `json:"deprecationReason"`
+}
+
+// IntrospectionInputValue is the __InputValue type of the GraphQL introspection system.
+type IntrospectionInputValue struct {
+	Name              string                `json:"name"`
+	Description       *string               `json:"description"`
+	Type              *IntrospectionTypeRef `json:"type"`
+	DefaultValue      *string               `json:"defaultValue"`
+	IsDeprecated      bool                  `json:"isDeprecated,omitempty"`
+	DeprecationReason *string               `json:"deprecationReason,omitempty"`
+}
+
+// IntrospectionEnumValue is the __EnumValue type of the GraphQL introspection system.
+type IntrospectionEnumValue struct {
+	Name              string  `json:"name"`
+	Description       *string `json:"description"`
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
+}
+
+// IntrospectionDirective is the __Directive type of the GraphQL introspection system.
+type IntrospectionDirective struct {
+	Name         string                     `json:"name"`
+	Description  *string                    `json:"description"`
+	IsRepeatable bool                       `json:"isRepeatable,omitempty"`
+	Locations    []string                   `json:"locations"`
+	Args         []*IntrospectionInputValue `json:"args"`
+}
+
+// introspectionOptions configure the introspection query.
+type introspectionOptions struct {
+	typeDepth             int
+	deprecated            bool
+	inputValueDeprecation bool
+	specifiedByURL        bool
+	directiveIsRepeatable bool
+}
+
+// IntrospectionOption are functions that are passed into Client.Introspect and IntrospectionQuery
+// to modify the introspection query.
+type IntrospectionOption func(*introspectionOptions)
+
+// WithTypeDepth sets how many levels of ofType are queried for type references (default: 7). Each
+// list or non-null wrapper of a type uses one level.
+//  client.Introspect(ctx, WithTypeDepth(10))
+func WithTypeDepth(depth int) IntrospectionOption {
+	return func(o *introspectionOptions) {
+		o.typeDepth = depth
+	}
+}
+
+// WithDeprecated sets whether deprecated fields and enum values are included (default: true).
+//  client.Introspect(ctx, WithDeprecated(false))
+func WithDeprecated(include bool) IntrospectionOption {
+	return func(o *introspectionOptions) {
+		o.deprecated = include
+	}
+}
+
+// WithInputValueDeprecation includes deprecated arguments and input fields, and queries whether
+// they are deprecated. Not every server supports this.
+//  client.Introspect(ctx, WithInputValueDeprecation())
+func WithInputValueDeprecation() IntrospectionOption {
+	return func(o *introspectionOptions) {
+		o.inputValueDeprecation = true
+	}
+}
+
+// WithSpecifiedByURL queries the specifiedByURL of scalar types. Not every server supports this.
+//  client.Introspect(ctx, WithSpecifiedByURL())
+func WithSpecifiedByURL() IntrospectionOption {
+	return func(o *introspectionOptions) {
+		o.specifiedByURL = true
+	}
+}
+
+// WithDirectiveIsRepeatable queries whether directives are repeatable. Not every server supports
+// this.
+//  client.Introspect(ctx, WithDirectiveIsRepeatable())
+func WithDirectiveIsRepeatable() IntrospectionOption {
+	return func(o *introspectionOptions) {
+		o.directiveIsRepeatable = true
+	}
+}
+
+// IntrospectionQuery returns the standard introspection query, modified by the given options.
+func IntrospectionQuery(opts ...IntrospectionOption) string {
+	o := introspectionOptions{
+		typeDepth:  7,
+		deprecated: true,
+	}
+	for _, optionFunc := range opts {
+		optionFunc(&o)
+	}
+
+	optional := func(enabled bool, s string) string {
+		if enabled {
+			return s
+		}
+		return ""
+	}
+	includeDeprecated := fmt.Sprintf("(includeDeprecated: %t)", o.deprecated)
+	inputValueArgs := optional(o.inputValueDeprecation, "(includeDeprecated: true)")
+
+	var q strings.Builder
+	q.WriteString(`query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      ` + optional(o.directiveIsRepeatable, "isRepeatable\n      ") + `locations
+      args` + inputValueArgs + ` { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  ` + optional(o.specifiedByURL, "specifiedByURL\n  ") + `fields` + includeDeprecated + ` {
+    name
+    description
+    args` + inputValueArgs + ` { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields` + inputValueArgs + ` { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues` + includeDeprecated + ` {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+` + optional(o.inputValueDeprecation, "  isDeprecated\n  deprecationReason\n") + `}
+
+fragment TypeRef on __Type {
+  kind
+  name`)
+	indent := "\n  "
+	for i := 0; i < o.typeDepth; i++ {
+		q.WriteString(indent + "ofType {")
+		indent += "  "
+		q.WriteString(indent + "kind")
+		q.WriteString(indent + "name")
+	}
+	for i := 0; i < o.typeDepth; i++ {
+		indent = indent[:len(indent)-2]
+		q.WriteString(indent + "}")
+	}
+	q.WriteString("\n}\n")
+	return q.String()
+}
+
+// Introspect runs the introspection query against the endpoint of the Client and builds the schema
+// from the result.
+//  schema, err := client.Introspect(ctx, WithSpecifiedByURL())
+func (c *Client) Introspect(ctx context.Context, opts ...IntrospectionOption) (*ast.Schema, error) {
+	var resp IntrospectionResponse
+	if err := c.Do(NewRequest(IntrospectionQuery(opts...), WithContext(ctx)), &resp); err != nil {
+		return nil, err
+	}
+	if resp.Schema == nil {
+		return nil, errors.New("introspection response has no schema")
+	}
+	return resp.Schema.BuildSchema()
+}
+
+// introspectionSource is the source of all the positions in a schema built from an introspection.
+var introspectionSource = &ast.Source{Name: "introspection"}
+
+// BuildSchema builds an ast.Schema from the introspection result. Whether directives are
+// repeatable cannot be represented in an ast.Schema and is dropped. The specifiedByURL of scalars
+// is represented as a @specifiedBy directive.
+func (s *IntrospectionSchema) BuildSchema() (*ast.Schema, error) {
+	doc, gerr := parser.ParseSchema(validator.Prelude)
+	if gerr != nil {
+		return nil, gerr
+	}
+	builtin := make(map[string]bool)
+	for _, def := range doc.Definitions {
+		builtin[def.Name] = true
+	}
+	for _, dir := range doc.Directives {
+		builtin["@"+dir.Name] = true
+	}
+
+	for _, t := range s.Types {
+		if builtin[t.Name] {
+			continue
+		}
+		def, err := buildDefinition(t)
+		if err != nil {
+			return nil, fmt.Errorf("type %s: %w", t.Name, err)
+		}
+		doc.Definitions = append(doc.Definitions, def)
+		if t.SpecifiedByURL != nil && !builtin["@specifiedBy"] {
+			// Servers that do not return the @specifiedBy directive still need its definition.
+			builtin["@specifiedBy"] = true
+			doc.Directives = append(doc.Directives, &ast.DirectiveDefinition{
+				Name: "specifiedBy",
+				Arguments: ast.ArgumentDefinitionList{{
+					Name: "url",
+					Type: ast.NonNullNamedType("String", nil),
+				}},
+				Locations: []ast.DirectiveLocation{ast.LocationScalar},
+				Position:  &ast.Position{Src: introspectionSource},
+			})
+		}
+	}
+
+	for _, d := range s.Directives {
+		if builtin["@"+d.Name] {
+			continue
+		}
+		builtin["@"+d.Name] = true
+		args, err := buildArgumentDefinitions(d.Args)
+		if err != nil {
+			return nil, fmt.Errorf("directive @%s: %w", d.Name, err)
+		}
+		dir := &ast.DirectiveDefinition{
+			Description: stringValue(d.Description),
+			Name:        d.Name,
+			Arguments:   args,
+			Position:    &ast.Position{Src: introspectionSource},
+		}
+		for _, location := range d.Locations {
+			dir.Locations = append(dir.Locations, ast.DirectiveLocation(location))
+		}
+		doc.Directives = append(doc.Directives, dir)
+	}
+
+	schemaDef := &ast.SchemaDefinition{Position: &ast.Position{Src: introspectionSource}}
+	roots := []struct {
+		operation ast.Operation
+		typ       *IntrospectionTypeName
+	}{
+		{ast.Query, s.QueryType},
+		{ast.Mutation, s.MutationType},
+		{ast.Subscription, s.SubscriptionType},
+	}
+	for _, root := range roots {
+		if root.typ != nil {
+			schemaDef.OperationTypes = append(schemaDef.OperationTypes, &ast.OperationTypeDefinition{
+				Operation: root.operation,
+				Type:      root.typ.Name,
+			})
+		}
+	}
+	doc.Schema = append(doc.Schema, schemaDef)
+
+	schema, gerr := validator.ValidateSchemaDocument(doc)
+	if gerr != nil {
+		return nil, gerr
+	}
+	return schema, nil
+}
+
+// buildDefinition converts an introspected named type into a type definition.
+func buildDefinition(t *IntrospectionType) (*ast.Definition, error) {
+	def := &ast.Definition{
+		Kind:        ast.DefinitionKind(t.Kind),
+		Description: stringValue(t.Description),
+		Name:        t.Name,
+		Position:    &ast.Position{Src: introspectionSource},
+	}
+	if t.SpecifiedByURL != nil {
+		def.Directives = append(def.Directives, &ast.Directive{
+			Name: "specifiedBy",
+			Arguments: ast.ArgumentList{{
+				Name:  "url",
+				Value: &ast.Value{Kind: ast.StringValue, Raw: *t.SpecifiedByURL},
+			}},
+		})
+	}
+
+	for _, f := range t.Fields {
+		typ, err := buildType(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		args, err := buildArgumentDefinitions(f.Args)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		def.Fields = append(def.Fields, &ast.FieldDefinition{
+			Description: stringValue(f.Description),
+			Name:        f.Name,
+			Arguments:   args,
+			Type:        typ,
+			Directives:  deprecatedDirectives(f.IsDeprecated, f.DeprecationReason),
+		})
+	}
+	for _, f := range t.InputFields {
+		field, err := buildInputValue(f)
+		if err != nil {
+			return nil, fmt.Errorf("input field %s: %w", f.Name, err)
+		}
+		def.Fields = append(def.Fields, &ast.FieldDefinition{
+			Description:  field.Description,
+			Name:         field.Name,
+			DefaultValue: field.DefaultValue,
+			Type:         field.Type,
+			Directives:   field.Directives,
+		})
+	}
+	for _, i := range t.Interfaces {
+		def.Interfaces = append(def.Interfaces, stringValue(i.Name))
+	}
+	if def.Kind == ast.Union {
+		for _, p := range t.PossibleTypes {
+			def.Types = append(def.Types, stringValue(p.Name))
+		}
+	}
+	for _, v := range t.EnumValues {
+		def.EnumValues = append(def.EnumValues, &ast.EnumValueDefinition{
+			Description: stringValue(v.Description),
+			Name:        v.Name,
+			Directives:  deprecatedDirectives(v.IsDeprecated, v.DeprecationReason),
+		})
+	}
+	return def, nil
+}
+
+// buildArgumentDefinitions converts introspected arguments into argument definitions.
+func buildArgumentDefinitions(values []*IntrospectionInputValue) (ast.ArgumentDefinitionList, error) {
+	var args ast.ArgumentDefinitionList
+	for _, v := range values {
+		arg, err := buildInputValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s: %w", v.Name, err)
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+// buildInputValue converts an introspected input value into an argument definition.
+func buildInputValue(v *IntrospectionInputValue) (*ast.ArgumentDefinition, error) {
+	typ, err := buildType(v.Type)
+	if err != nil {
+		return nil, err
+	}
+	arg := &ast.ArgumentDefinition{
+		Description: stringValue(v.Description),
+		Name:        v.Name,
+		Type:        typ,
+		Directives:  deprecatedDirectives(v.IsDeprecated, v.DeprecationReason),
+	}
+	if v.DefaultValue != nil {
+		if arg.DefaultValue, err = parseValue(*v.DefaultValue); err != nil {
+			return nil, fmt.Errorf("default value: %w", err)
+		}
+	}
+	return arg, nil
+}
+
+// buildType converts an introspected type reference into a type.
+func buildType(ref *IntrospectionTypeRef) (*ast.Type, error) {
+	if ref == nil {
+		return nil, errors.New("type reference is deeper than the queried type depth")
+	}
+	switch ref.Kind {
+	case "NON_NULL":
+		typ, err := buildType(ref.OfType)
+		if err != nil {
+			return nil, err
+		}
+		typ.NonNull = true
+		return typ, nil
+	case "LIST":
+		elem, err := buildType(ref.OfType)
+		if err != nil {
+			return nil, err
+		}
+		return ast.ListType(elem, nil), nil
+	default:
+		if ref.Name == nil {
+			return nil, fmt.Errorf("%s type reference has no name", ref.Kind)
+		}
+		return ast.NamedType(*ref.Name, nil), nil
+	}
+}
+
+// deprecatedDirectives returns the @deprecated directive for deprecated fields and values.
+func deprecatedDirectives(isDeprecated bool, reason *string) ast.DirectiveList {
+	if !isDeprecated {
+		return nil
+	}
+	dir := &ast.Directive{Name: "deprecated"}
+	if reason != nil {
+		dir.Arguments = ast.ArgumentList{{
+			Name:  "reason",
+			Value: &ast.Value{Kind: ast.StringValue, Raw: *reason},
+		}}
+	}
+	return ast.DirectiveList{dir}
+}
+
+// parseValue parses a GraphQL value literal, like the default values in an introspection result.
+func parseValue(literal string) (*ast.Value, error) {
+	doc, gerr := parser.ParseQuery(&ast.Source{Input: "{f(v: " + literal + ")}"})
+	if gerr != nil {
+		return nil, gerr
+	}
+	field, ok := doc.Operations[0].SelectionSet[0].(*ast.Field)
+	if !ok || len(field.Arguments) != 1 {
+		return nil, fmt.Errorf("invalid value %q", literal)
+	}
+	return field.Arguments[0].Value, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,123 @@
+package gqlclient_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteIntrospection struct {
+	suite.Suite
+}
+
+func TestSuiteIntrospection(t *testing.T) {
+	s := SuiteIntrospection{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteIntrospection) introspect(opts ...gql.IntrospectionOption) (*ast.Schema, error) {
+	body, err := os.Open("testdata/introspection.json")
+	s.Require().NoError(err)
+
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Body:       body,
+			StatusCode: http.StatusOK,
+		}, nil)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient))
+	return c.Introspect(context.Background(), opts...)
+}
+
+func (s *SuiteIntrospection) TestIntrospect() {
+	schema, err := s.introspect()
+	s.Require().NoError(err)
+
+	s.Equal("Root", schema.Query.Name)
+	s.Nil(schema.Mutation)
+
+	user := schema.Types["User"]
+	s.Require().NotNil(user)
+	s.Equal(ast.Object, user.Kind)
+	s.Equal("A user of the system.", user.Description)
+	s.Equal([]string{"Node"}, user.Interfaces)
+	s.Equal("ID!", user.Fields.ForName("id").Type.String())
+
+	name := user.Fields.ForName("name")
+	s.Equal(`"full"`, name.Arguments.ForName("format").DefaultValue.String())
+	deprecated := name.Directives.ForName("deprecated")
+	s.Require().NotNil(deprecated)
+	s.Equal("Use fullName.", deprecated.Arguments.ForName("reason").Value.Raw)
+
+	s.Equal("[User!]!", schema.Query.Fields.ForName("users").Type.String())
+	s.Equal([]string{"User"}, schema.Types["SearchResult"].Types)
+	s.Equal("USER", schema.Types["UserFilter"].Fields.ForName("role").DefaultValue.String())
+	s.Len(schema.Types["Role"].EnumValues, 2)
+	s.Len(schema.GetPossibleTypes(schema.Types["Node"]), 1)
+
+	specifiedBy := schema.Types["DateTime"].Directives.ForName("specifiedBy")
+	s.Require().NotNil(specifiedBy)
+	s.Equal("https://tools.ietf.org/html/rfc3339", specifiedBy.Arguments.ForName("url").Value.Raw)
+	s.NotNil(schema.Directives["tag"])
+	s.NotNil(schema.Directives["include"])
+}
+
+func (s *SuiteIntrospection) TestIntrospectionQuery() {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Run(func(args mock.Arguments) {
+			r := args.Get(0).(*http.Request)
+			body, err := ioutil.ReadAll(r.Body)
+			s.Require().NoError(err)
+			s.Contains(string(body), "specifiedByURL")
+			s.Contains(string(body), "isRepeatable")
+		}).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data": {"__schema": null}}`)),
+			StatusCode: http.StatusOK,
+		}, nil).
+		Once()
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient))
+	_, err := c.Introspect(context.Background(), gql.WithSpecifiedByURL(), gql.WithDirectiveIsRepeatable())
+	httpClient.AssertExpectations(s.T())
+	s.Error(err)
+}
+
+func (s *SuiteIntrospection) TestTypeDepth() {
+	query := gql.IntrospectionQuery(gql.WithTypeDepth(2))
+	s.NotContains(query, "specifiedByURL")
+	s.NotContains(query, "isRepeatable")
+	s.Contains(query, "fields(includeDeprecated: true)")
+	s.Equal(2, strings.Count(query, "ofType {"))
+}
+
+func (s *SuiteIntrospection) TestTypeDepthExceeded() {
+	schema := &gql.IntrospectionSchema{
+		QueryType: &gql.IntrospectionTypeName{Name: "Query"},
+		Types: []*gql.IntrospectionType{{
+			Kind: "OBJECT",
+			Name: "Query",
+			Fields: []*gql.IntrospectionField{{
+				Name: "values",
+				// A NON_NULL type without an ofType is cut off by the type depth.
+				Type: &gql.IntrospectionTypeRef{Kind: "NON_NULL"},
+			}},
+		}},
+	}
+	_, err := schema.BuildSchema()
+	s.Error(err)
+}
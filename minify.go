@@ -0,0 +1,267 @@
+package gqlclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// MinifyQuery parses the query and prints it again without comments, commas and insignificant
+// whitespace. Block strings are printed as regular strings. An invalid query returns a
+// *ValidationError.
+//  MinifyQuery("query ($id: ID!) {\n  user(id: $id) { name } # comment\n}")
+//  // query($id:ID!){user(id:$id){name}}
+func MinifyQuery(query string) (string, error) {
+	doc, err := parseQuery(query)
+	if err != nil {
+		return "", err
+	}
+	return minifyDocument(doc), nil
+}
+
+// NormalizeQuery returns the minified query with its operations and fragments sorted by name, so that
+// queries which only differ in whitespace, comments or the order of their definitions have the same
+// normalized form. An invalid query returns a *ValidationError.
+func NormalizeQuery(query string) (string, error) {
+	doc, err := parseQuery(query)
+	if err != nil {
+		return "", err
+	}
+	return normalizeDocument(doc), nil
+}
+
+// normalizeDocument returns the minified document with its operations and fragments sorted by name.
+// The document itself is not modified, as it may be shared.
+func normalizeDocument(doc *ast.QueryDocument) string {
+	sorted := *doc
+	sorted.Operations = append(ast.OperationList(nil), doc.Operations...)
+	sorted.Fragments = append(ast.FragmentDefinitionList(nil), doc.Fragments...)
+	sort.SliceStable(sorted.Operations, func(i, j int) bool {
+		return sorted.Operations[i].Name < sorted.Operations[j].Name
+	})
+	sort.SliceStable(sorted.Fragments, func(i, j int) bool {
+		return sorted.Fragments[i].Name < sorted.Fragments[j].Name
+	})
+	return minifyDocument(&sorted)
+}
+
+// QueryHash returns the hex encoded SHA-256 hash of the normalized query. Queries with the same
+// normalized form have the same hash, which can be used as the hash of an automatic persisted query
+// or as a cache key.
+func QueryHash(query string) (string, error) {
+	normalized, err := NormalizeQuery(query)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func parseQuery(query string) (*ast.QueryDocument, error) {
+	doc, gerr := parser.ParseQuery(&ast.Source{Input: query})
+	if gerr != nil {
+		return nil, &ValidationError{Errors: gqlerror.List{gerr}}
+	}
+	return doc, nil
+}
+
+// WithQueryNormalization sends the normalized form of the query of every Request, as returned by
+// NormalizeQuery, which is minified. Queries that cannot be parsed are not sent and return a
+// *ValidationError. The normalized queries are cached in the query cache of the Client.
+//  NewClient(endpoint, WithQueryNormalization())
+func WithQueryNormalization() ClientOption {
+	return func(client *Client) {
+		client.normalize = true
+	}
+}
+
+// minifier prints a document without insignificant characters. A space is only written between two
+// tokens that would otherwise be read as one token.
+type minifier struct {
+	b strings.Builder
+}
+
+func minifyDocument(doc *ast.QueryDocument) string {
+	var m minifier
+	for _, op := range doc.Operations {
+		m.operation(op)
+	}
+	for _, frag := range doc.Fragments {
+		m.fragment(frag)
+	}
+	return m.b.String()
+}
+
+// token writes a token, preceded by a space when both the previous and this token are names or
+// numbers.
+func (m *minifier) token(s string) {
+	if m.b.Len() > 0 && isNameChar(m.b.String()[m.b.Len()-1]) && isNameChar(s[0]) {
+		m.b.WriteByte(' ')
+	}
+	m.b.WriteString(s)
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '-' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func (m *minifier) operation(op *ast.OperationDefinition) {
+	if op.Name == "" && len(op.VariableDefinitions) == 0 && len(op.Directives) == 0 && op.Operation == ast.Query {
+		// Shorthand query.
+		m.selectionSet(op.SelectionSet)
+		return
+	}
+	m.token(string(op.Operation))
+	if op.Name != "" {
+		m.token(op.Name)
+	}
+	m.variableDefinitions(op.VariableDefinitions)
+	m.directives(op.Directives)
+	m.selectionSet(op.SelectionSet)
+}
+
+func (m *minifier) fragment(frag *ast.FragmentDefinition) {
+	m.token("fragment")
+	m.token(frag.Name)
+	m.variableDefinitions(frag.VariableDefinition)
+	m.token("on")
+	m.token(frag.TypeCondition)
+	m.directives(frag.Directives)
+	m.selectionSet(frag.SelectionSet)
+}
+
+func (m *minifier) variableDefinitions(defs ast.VariableDefinitionList) {
+	if len(defs) == 0 {
+		return
+	}
+	m.token("(")
+	for _, def := range defs {
+		m.token("$")
+		m.token(def.Variable)
+		m.token(":")
+		m.token(def.Type.String())
+		if def.DefaultValue != nil {
+			m.token("=")
+			m.value(def.DefaultValue)
+		}
+	}
+	m.token(")")
+}
+
+func (m *minifier) selectionSet(set ast.SelectionSet) {
+	if len(set) == 0 {
+		return
+	}
+	m.token("{")
+	for _, selection := range set {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Alias != "" && selection.Alias != selection.Name {
+				m.token(selection.Alias)
+				m.token(":")
+			}
+			m.token(selection.Name)
+			m.arguments(selection.Arguments)
+			m.directives(selection.Directives)
+			m.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			m.token("...")
+			m.token(selection.Name)
+			m.directives(selection.Directives)
+		case *ast.InlineFragment:
+			m.token("...")
+			if selection.TypeCondition != "" {
+				m.token("on")
+				m.token(selection.TypeCondition)
+			}
+			m.directives(selection.Directives)
+			m.selectionSet(selection.SelectionSet)
+		}
+	}
+	m.token("}")
+}
+
+func (m *minifier) arguments(args ast.ArgumentList) {
+	if len(args) == 0 {
+		return
+	}
+	m.token("(")
+	for _, arg := range args {
+		m.token(arg.Name)
+		m.token(":")
+		m.value(arg.Value)
+	}
+	m.token(")")
+}
+
+func (m *minifier) directives(dirs ast.DirectiveList) {
+	for _, dir := range dirs {
+		m.token("@")
+		m.token(dir.Name)
+		m.arguments(dir.Arguments)
+	}
+}
+
+func (m *minifier) value(v *ast.Value) {
+	switch v.Kind {
+	case ast.Variable:
+		m.token("$")
+		m.token(v.Raw)
+	case ast.StringValue, ast.BlockValue:
+		m.token(quoteString(v.Raw))
+	case ast.ListValue:
+		m.token("[")
+		for _, child := range v.Children {
+			m.value(child.Value)
+		}
+		m.token("]")
+	case ast.ObjectValue:
+		m.token("{")
+		for _, child := range v.Children {
+			m.token(child.Name)
+			m.token(":")
+			m.value(child.Value)
+		}
+		m.token("}")
+	default:
+		m.token(v.Raw)
+	}
+}
+
+// quoteString returns a GraphQL string literal for the string.
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,125 @@
+package gqlclient_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteMinify struct {
+	suite.Suite
+}
+
+func TestSuiteMinify(t *testing.T) {
+	s := SuiteMinify{}
+	suite.Run(t, &s)
+}
+
+// format parses and formats a query, to compare the documents of queries.
+func (s *SuiteMinify) format(query string) string {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	s.Require().Nil(err, query)
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatQueryDocument(doc)
+	return buf.String()
+}
+
+func (s *SuiteMinify) TestMinifyQuery() {
+	tests := map[string]string{
+		"{ user(id: 1) { name } }": "{user(id:1){name}}",
+		`# Get a user.
+		query GetUser($id: ID!, $size: Int = 64) @cached {
+			me: user(id: $id) {
+				id,
+				avatar(size: $size)
+				... on User @include(if: true) { name }
+				...Friends
+			}
+		}
+		fragment Friends on User { friends(first: 10) { id } }`: "query GetUser($id:ID!$size:Int=64)@cached" +
+			"{me:user(id:$id){id avatar(size:$size)...on User@include(if:true){name}...Friends}}" +
+			"fragment Friends on User{friends(first:10){id}}",
+		`mutation { rename(id: "1", name: """Bob "the" builder""") { id } }`: `mutation{rename(id:"1"name:"Bob \"the\" builder"){id}}`,
+		`{ users(ids: [1, 2, -3], filter: {name: "é\n\\", roles: [ADMIN, USER]}, min: 1.5e3) { id } }`: "" +
+			`{users(ids:[1 2 -3]filter:{name:"é\n\\"roles:[ADMIN USER]}min:1.5e3){id}}`,
+	}
+	for query, expected := range tests {
+		minified, err := gql.MinifyQuery(query)
+		s.Require().NoError(err)
+		s.Equal(expected, minified)
+		s.Equal(s.format(query), s.format(minified))
+	}
+
+	_, err := gql.MinifyQuery("{ user(id: 1) { name }")
+	var verr *gql.ValidationError
+	s.ErrorAs(err, &verr)
+}
+
+func (s *SuiteMinify) TestNormalizeQuery() {
+	a := `
+		query B { user(id: 1) { ...F } }
+		query A { user(id: 2) { ...F } }
+		fragment F on User { name }`
+	b := `fragment F on User {
+		name
+	}
+	# Operations in another order.
+	query A { user(id: 2) { ...F } } query B { user(id: 1) { ...F } }`
+
+	normalized, err := gql.NormalizeQuery(a)
+	s.Require().NoError(err)
+	s.Equal("query A{user(id:2){...F}}query B{user(id:1){...F}}fragment F on User{name}", normalized)
+
+	hashA, err := gql.QueryHash(a)
+	s.Require().NoError(err)
+	hashB, err := gql.QueryHash(b)
+	s.Require().NoError(err)
+	s.Equal(hashA, hashB)
+	s.Len(hashA, 64)
+
+	hashC, err := gql.QueryHash(strings.Replace(a, "name", "id", 1))
+	s.Require().NoError(err)
+	s.NotEqual(hashA, hashC)
+}
+
+func (s *SuiteMinify) TestWithQueryNormalization() {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			var body struct {
+				Query string
+			}
+			return json.NewDecoder(req.Body).Decode(&body) == nil &&
+				body.Query == "query($id:ID!){user(id:$id){name}}"
+		})).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data": {}}`)),
+			StatusCode: http.StatusOK,
+		}, nil)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient), gql.WithQueryNormalization(),
+		gql.WithSchema(structQuerySchema))
+	req := gql.NewRequest(`query ($id: ID!) {
+		user(id: $id) {
+			name
+		}
+	}`, gql.WithVar("id", "1"))
+	s.NoError(c.Do(req, nil))
+	httpClient.AssertExpectations(s.T())
+
+	err := c.Do(gql.NewRequest("{ user(id: 1) { name }"), nil)
+	var verr *gql.ValidationError
+	s.ErrorAs(err, &verr)
+}
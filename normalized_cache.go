@@ -0,0 +1,429 @@
+package gqlclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// CachePolicy controls how the normalized cache is used for a query.
+type CachePolicy int
+
+const (
+	// CacheFirst answers the query from the cache when all its fields are cached, and sends it
+	// otherwise. This is the default.
+	CacheFirst CachePolicy = iota
+	// NetworkOnly always sends the query, and writes the response to the cache.
+	NetworkOnly
+	// CacheAndNetwork answers the query from the cache when all its fields are cached, and sends it
+	// in the background to update the cache. The query is sent in the foreground when its fields are
+	// not cached. The background query keeps the values of the Context of the Request, but is not
+	// cancelled with it, and times out after backgroundTimeout.
+	CacheAndNetwork
+)
+
+// WithCachePolicy sets the CachePolicy for the query of a Request, when the Client has a
+// NormalizedCache.
+//  NewRequest(query, WithCachePolicy(NetworkOnly))
+func WithCachePolicy(policy CachePolicy) RequestOption {
+	return func(r *Request) {
+		r.cachePolicy = policy
+	}
+}
+
+// NormalizedCache caches the data of responses as entities, which are shared between queries. An
+// object in a response is an entity when it has a __typename and key fields, which default to id.
+// Other objects are cached as part of the entity or query that contains them. Queries are answered
+// from the cache when all their fields are cached, and the responses of both queries and mutations
+// update the cached entities.
+//
+// Select __typename and id for objects to share them between queries. Fragments are matched against
+// the __typename of cached objects, or against the possible types in the schema of the Client when
+// it has one.
+type NormalizedCache struct {
+	keyFields map[string][]string
+
+	mu       sync.RWMutex
+	entities map[string]map[string]interface{}
+}
+
+// NormalizedCacheOption are functions that are passed into NewNormalizedCache to modify the cache.
+type NormalizedCacheOption func(*NormalizedCache)
+
+// WithKeyFields sets the fields that identify the entities of a type, instead of id.
+//  NewNormalizedCache(WithKeyFields("Book", "isbn"))
+func WithKeyFields(typename string, fields ...string) NormalizedCacheOption {
+	return func(c *NormalizedCache) {
+		c.keyFields[typename] = fields
+	}
+}
+
+// NewNormalizedCache makes a new, empty NormalizedCache.
+func NewNormalizedCache(opts ...NormalizedCacheOption) *NormalizedCache {
+	c := &NormalizedCache{
+		keyFields: make(map[string][]string),
+		entities:  make(map[string]map[string]interface{}),
+	}
+	for _, optionFunc := range opts {
+		optionFunc(c)
+	}
+	return c
+}
+
+// Clear removes all the entities from the cache.
+func (c *NormalizedCache) Clear() {
+	c.mu.Lock()
+	c.entities = make(map[string]map[string]interface{})
+	c.mu.Unlock()
+}
+
+// WithNormalizedCache uses the NormalizedCache for the queries and mutations that are executed with
+// Do. The CachePolicy of a query is set with WithCachePolicy.
+//  NewClient(endpoint, WithNormalizedCache(NewNormalizedCache()))
+func WithNormalizedCache(cache *NormalizedCache) ClientOption {
+	return func(client *Client) {
+		client.cache = cache
+	}
+}
+
+// doCached executes the Request for a cached operation, answering queries from the cache according
+// to the CachePolicy of the Request.
+func (c *Client) doCached(req *Request, op *cachedOperation, resp interface{}) error {
+	if op.op.Operation == ast.Query && req.cachePolicy != NetworkOnly {
+		if data, ok := op.read(); ok {
+			if req.cachePolicy == CacheAndNetwork {
+				// The caller usually cancels its Context when Do returns.
+				ctx, cancel := context.WithTimeout(detachedContext{req.ctx}, backgroundTimeout)
+				refresh := *req
+				refresh.ctx = ctx
+				go func() {
+					defer cancel()
+					_ = c.doNetwork(&refresh, op, nil)
+				}()
+			}
+			if resp == nil {
+				return nil
+			}
+			b, err := c.codec.Marshal(data)
+			if err != nil {
+				return fmt.Errorf("encode cached data: %w", err)
+			}
+			return c.decodeOptionsFor(req).decodeData(c.codec, b, resp)
+		}
+	}
+	return c.doNetwork(req, op, resp)
+}
+
+// doNetwork sends the Request, writes the data of the response to the cache when there are no
+// errors, and decodes it into resp.
+func (c *Client) doNetwork(req *Request, op *cachedOperation, resp interface{}) error {
+	raw, err := c.doRaw(req)
+	if err != nil {
+		return err
+	}
+	if len(raw.Errors) == 0 && len(raw.Data) > 0 && string(raw.Data) != "null" {
+		if err := op.write(raw.Data); err != nil {
+			return err
+		}
+	}
+	return c.decodeRaw(req, raw, resp)
+}
+
+// entityRef is a reference to an entity in the cache.
+type entityRef string
+
+// cachedOperation is an operation of a Request that can use the cache.
+type cachedOperation struct {
+	cache     *NormalizedCache
+	doc       *ast.QueryDocument
+	op        *ast.OperationDefinition
+	variables map[string]interface{}
+	schema    *ast.Schema
+}
+
+// operation returns the operation of the Request, or nil when the query is not a single query or
+// mutation.
+func (c *NormalizedCache) operation(req *Request, q *cachedQuery, schema *ast.Schema) *cachedOperation {
+	// Queries that cannot be parsed are sent to get the error.
+	doc, err := q.document()
+	if err != nil || len(doc.Operations) != 1 || doc.Operations[0].Operation == ast.Subscription {
+		return nil
+	}
+	return &cachedOperation{cache: c, doc: doc, op: doc.Operations[0], variables: req.Variables, schema: schema}
+}
+
+// rootKey returns the key of the entity that holds the root fields of the operation.
+func (o *cachedOperation) rootKey() string {
+	if o.op.Operation == ast.Mutation {
+		return "ROOT_MUTATION"
+	}
+	return "ROOT_QUERY"
+}
+
+// read returns the data of the response from the cache, when all the fields are cached.
+func (o *cachedOperation) read() (map[string]interface{}, bool) {
+	o.cache.mu.RLock()
+	defer o.cache.mu.RUnlock()
+
+	root, ok := o.cache.entities[o.rootKey()]
+	if !ok {
+		return nil, false
+	}
+	return o.readObject(root, o.op.SelectionSet)
+}
+
+func (o *cachedOperation) readObject(object map[string]interface{}, set ast.SelectionSet) (map[string]interface{}, bool) {
+	typename, _ := object["__typename"].(string)
+	data := make(map[string]interface{})
+	for _, field := range o.collectFields(set, typename, true) {
+		key, err := o.fieldKey(field.field)
+		if err != nil {
+			return nil, false
+		}
+		value, ok := object[key]
+		if !ok {
+			return nil, false
+		}
+		if data[field.alias], ok = o.readValue(value, field.selections); !ok {
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+func (o *cachedOperation) readValue(value interface{}, set ast.SelectionSet) (interface{}, bool) {
+	switch value := value.(type) {
+	case entityRef:
+		entity, ok := o.cache.entities[string(value)]
+		if !ok {
+			return nil, false
+		}
+		return o.readObject(entity, set)
+	case map[string]interface{}:
+		if len(set) == 0 {
+			return value, true
+		}
+		return o.readObject(value, set)
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, elem := range value {
+			var ok bool
+			if list[i], ok = o.readValue(elem, set); !ok {
+				return nil, false
+			}
+		}
+		return list, true
+	}
+	return value, true
+}
+
+// write writes the data of a response to the cache.
+func (o *cachedOperation) write(data json.RawMessage) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var object map[string]interface{}
+	if err := dec.Decode(&object); err != nil {
+		return fmt.Errorf("decode data: %w", err)
+	}
+
+	o.cache.mu.Lock()
+	defer o.cache.mu.Unlock()
+
+	root, ok := o.cache.entities[o.rootKey()]
+	if !ok {
+		root = make(map[string]interface{})
+		o.cache.entities[o.rootKey()] = root
+	}
+	return o.writeObject(root, object, o.op.SelectionSet)
+}
+
+func (o *cachedOperation) writeObject(target, object map[string]interface{}, set ast.SelectionSet) error {
+	typename, _ := object["__typename"].(string)
+	for _, field := range o.collectFields(set, typename, false) {
+		value, ok := object[field.alias]
+		if !ok {
+			// The field is in a fragment that does not apply, or it is skipped.
+			continue
+		}
+		key, err := o.fieldKey(field.field)
+		if err != nil {
+			return err
+		}
+		if target[key], err = o.writeValue(value, field.selections); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *cachedOperation) writeValue(value interface{}, set ast.SelectionSet) (interface{}, error) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if len(set) == 0 {
+			// An object of a custom scalar type.
+			return value, nil
+		}
+		key, ok := o.cache.entityKey(value)
+		if !ok {
+			object := make(map[string]interface{})
+			return object, o.writeObject(object, value, set)
+		}
+		entity, ok := o.cache.entities[key]
+		if !ok {
+			entity = make(map[string]interface{})
+			o.cache.entities[key] = entity
+		}
+		return entityRef(key), o.writeObject(entity, value, set)
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for i, elem := range value {
+			var err error
+			if list[i], err = o.writeValue(elem, set); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	return value, nil
+}
+
+// entityKey returns the key of the entity for an object in a response, which consists of its
+// __typename and the values of its key fields.
+func (c *NormalizedCache) entityKey(object map[string]interface{}) (string, bool) {
+	typename, ok := object["__typename"].(string)
+	if !ok {
+		return "", false
+	}
+	fields, ok := c.keyFields[typename]
+	if !ok {
+		fields = []string{"id"}
+	}
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		if values[i], ok = object[field]; !ok || values[i] == nil {
+			return "", false
+		}
+	}
+	key, err := json.Marshal(values)
+	if err != nil {
+		return "", false
+	}
+	return typename + ":" + string(key), true
+}
+
+// fieldKey returns the key that the value of a field is cached with, which contains the values of
+// its arguments.
+func (o *cachedOperation) fieldKey(field *ast.Field) (string, error) {
+	if len(field.Arguments) == 0 {
+		return field.Name, nil
+	}
+	args := make(map[string]interface{}, len(field.Arguments))
+	for _, arg := range field.Arguments {
+		value, err := arg.Value.Value(o.variables)
+		if err != nil {
+			return "", err
+		}
+		args[arg.Name] = value
+	}
+	b, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	return field.Name + string(b), nil
+}
+
+// collectedField is a field in a selection set, with the selections of all the fields with the
+// same response name.
+type collectedField struct {
+	alias      string
+	field      *ast.Field
+	selections ast.SelectionSet
+}
+
+// collectFields returns the fields of a selection set, including the fields of the fragments that
+// apply to the typename. When reading, a fragment with another type condition applies when it is an
+// abstract type that includes the typename, or when that cannot be known without a schema. When
+// writing, all fragments apply, since the response only contains fields of applicable fragments.
+func (o *cachedOperation) collectFields(set ast.SelectionSet, typename string, reading bool) []*collectedField {
+	var fields []*collectedField
+	var collect func(set ast.SelectionSet)
+	collect = func(set ast.SelectionSet) {
+		for _, selection := range set {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				if !o.included(selection.Directives) {
+					continue
+				}
+				alias := selection.Alias
+				if alias == "" {
+					alias = selection.Name
+				}
+				var field *collectedField
+				for _, f := range fields {
+					if f.alias == alias {
+						field = f
+					}
+				}
+				if field == nil {
+					field = &collectedField{alias: alias, field: selection}
+					fields = append(fields, field)
+				}
+				field.selections = append(field.selections, selection.SelectionSet...)
+			case *ast.InlineFragment:
+				if o.included(selection.Directives) && (!reading || o.applies(selection.TypeCondition, typename)) {
+					collect(selection.SelectionSet)
+				}
+			case *ast.FragmentSpread:
+				def := o.doc.Fragments.ForName(selection.Name)
+				if def != nil && o.included(selection.Directives) && (!reading || o.applies(def.TypeCondition, typename)) {
+					collect(def.SelectionSet)
+				}
+			}
+		}
+	}
+	collect(set)
+	return fields
+}
+
+// applies reports whether a fragment with the type condition applies to an object with the
+// typename.
+func (o *cachedOperation) applies(typeCondition, typename string) bool {
+	if typeCondition == "" || typename == "" || typeCondition == typename {
+		return true
+	}
+	if o.schema == nil {
+		return true
+	}
+	def := o.schema.Types[typeCondition]
+	if def == nil || !def.IsAbstractType() {
+		return false
+	}
+	for _, possible := range o.schema.GetPossibleTypes(def) {
+		if possible.Name == typename {
+			return true
+		}
+	}
+	return false
+}
+
+// included evaluates the skip and include directives of a selection.
+func (o *cachedOperation) included(directives ast.DirectiveList) bool {
+	for _, dir := range directives {
+		if dir.Name != "skip" && dir.Name != "include" {
+			continue
+		}
+		arg := dir.Arguments.ForName("if")
+		if arg == nil {
+			continue
+		}
+		value, err := arg.Value.Value(o.variables)
+		if b, ok := value.(bool); err == nil && ok && b == (dir.Name == "skip") {
+			return false
+		}
+	}
+	return true
+}
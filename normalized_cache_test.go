@@ -0,0 +1,276 @@
+package gqlclient_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+var cacheSchema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Query {
+		user(id: ID!): User
+		node(id: ID!): Node
+		book(isbn: String!): Book
+	}
+	type Mutation {
+		rename(id: ID!, name: String!): User!
+	}
+	interface Node {
+		id: ID!
+	}
+	type User implements Node {
+		id: ID!
+		name: String!
+		email: String
+		friends(first: Int): [User!]!
+	}
+	type Post implements Node {
+		id: ID!
+		title: String!
+	}
+	type Book {
+		isbn: String!
+		title: String!
+	}
+`})
+
+const userQuery = `query ($id: ID!) { user(id: $id) { __typename id name } }`
+
+type cachedUser struct {
+	User struct {
+		ID   string
+		Name string
+	}
+}
+
+type SuiteNormalizedCache struct {
+	suite.Suite
+	httpClient *mocks.HTTPClient
+	sent       chan string
+}
+
+func TestSuiteNormalizedCache(t *testing.T) {
+	s := SuiteNormalizedCache{}
+	suite.Run(t, &s)
+}
+
+// client returns a client with a normalized cache, which receives the response bodies in order.
+func (s *SuiteNormalizedCache) client(opts []gql.ClientOption, bodies ...string) *gql.Client {
+	s.httpClient = new(mocks.HTTPClient)
+	s.sent = make(chan string, len(bodies))
+	for _, body := range bodies {
+		body := body
+		s.httpClient.
+			On("Do", mock.AnythingOfType("*http.Request")).
+			Return(func(req *http.Request) *http.Response {
+				b, _ := ioutil.ReadAll(req.Body)
+				s.sent <- string(b)
+				return &http.Response{
+					Body:       ioutil.NopCloser(strings.NewReader(body)),
+					StatusCode: http.StatusOK,
+				}
+			}, nil).
+			Once()
+	}
+	opts = append(opts, gql.WithHTTPClient(s.httpClient))
+	return gql.NewClient("test", opts...)
+}
+
+func (s *SuiteNormalizedCache) TestCacheFirst() {
+	c := s.client([]gql.ClientOption{gql.WithNormalizedCache(gql.NewNormalizedCache())},
+		`{"data": {"user": {"__typename": "User", "id": "1", "name": "Bob"}}}`,
+		`{"data": {"user": {"__typename": "User", "id": "2", "name": "Alice"}}}`,
+		`{"data": {"user": {"id": "1", "name": "Bob", "email": null}}}`,
+	)
+
+	for i := 0; i < 2; i++ {
+		var resp cachedUser
+		s.Require().NoError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1")), &resp))
+		s.Equal("Bob", resp.User.Name)
+	}
+	var resp cachedUser
+	s.Require().NoError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "2")), &resp))
+	s.Equal("Alice", resp.User.Name)
+
+	// Selecting a field that is not cached sends the query.
+	s.NoError(c.Do(gql.NewRequest(`{ user(id: "1") { id name email } }`), nil))
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 3)
+}
+
+func (s *SuiteNormalizedCache) TestMutationUpdatesEntities() {
+	c := s.client([]gql.ClientOption{gql.WithNormalizedCache(gql.NewNormalizedCache())},
+		`{"data": {"user": {"__typename": "User", "id": "1", "name": "Bob", "best": [
+			{"__typename": "User", "id": "2", "name": "Alice"}
+		]}}}`,
+		`{"data": {"rename": {"__typename": "User", "id": "2", "name": "Eve"}}}`,
+	)
+	query := `{ user(id: "1") { __typename id name best: friends(first: 1) { __typename id name } } }`
+	var resp struct {
+		User struct {
+			Name string
+			Best []struct {
+				Name string
+			}
+		}
+	}
+	s.Require().NoError(c.Do(gql.NewRequest(query), &resp))
+	s.Equal("Alice", resp.User.Best[0].Name)
+
+	s.Require().NoError(c.Do(gql.NewRequest(`mutation { rename(id: "2", name: "Eve") { __typename id name } }`), nil))
+
+	s.Require().NoError(c.Do(gql.NewRequest(query), &resp))
+	s.Equal("Bob", resp.User.Name)
+	s.Equal("Eve", resp.User.Best[0].Name)
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 2)
+}
+
+func (s *SuiteNormalizedCache) TestCachePolicies() {
+	cache := gql.NewNormalizedCache()
+	c := s.client([]gql.ClientOption{gql.WithNormalizedCache(cache)},
+		`{"data": {"user": {"__typename": "User", "id": "1", "name": "Bob"}}}`,
+		`{"data": {"user": {"__typename": "User", "id": "1", "name": "Alice"}}}`,
+		`{"data": {"user": {"__typename": "User", "id": "1", "name": "Eve"}}}`,
+	)
+
+	var resp cachedUser
+	s.Require().NoError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1")), &resp))
+	s.Require().NoError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1"),
+		gql.WithCachePolicy(gql.NetworkOnly)), &resp))
+	s.Equal("Alice", resp.User.Name)
+
+	// The cached data is returned, and the cache is updated in the background.
+	s.Require().NoError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1"),
+		gql.WithCachePolicy(gql.CacheAndNetwork)), &resp))
+	s.Equal("Alice", resp.User.Name)
+	for i := 0; i < 3; i++ {
+		<-s.sent
+	}
+	s.Eventually(func() bool {
+		var resp cachedUser
+		err := c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1")), &resp)
+		return err == nil && resp.User.Name == "Eve"
+	}, time.Second, time.Millisecond)
+
+	// Without entities, the query is sent again.
+	cache.Clear()
+	s.httpClient.On("Do", mock.Anything).Return(nil, errors.New("sent"))
+	s.EqualError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1")), &resp), "do request: sent")
+}
+
+func (s *SuiteNormalizedCache) TestCacheAndNetworkCancelledContext() {
+	c := s.client([]gql.ClientOption{gql.WithNormalizedCache(gql.NewNormalizedCache())},
+		`{"data": {"user": {"__typename": "User", "id": "1", "name": "Bob"}}}`,
+	)
+	var resp cachedUser
+	s.Require().NoError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1")), &resp))
+	<-s.sent
+
+	// The background query is not cancelled with the Context of the caller.
+	release := make(chan struct{})
+	s.httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) *http.Response {
+			<-release
+			return &http.Response{
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data": {"user": {"__typename": "User", "id": "1", "name": "Eve"}}}`)),
+				StatusCode: http.StatusOK,
+			}
+		}, func(req *http.Request) error {
+			return req.Context().Err()
+		}).
+		Once()
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Require().NoError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1"), gql.WithContext(ctx),
+		gql.WithCachePolicy(gql.CacheAndNetwork)), &resp))
+	s.Equal("Bob", resp.User.Name)
+	cancel()
+	close(release)
+
+	s.Eventually(func() bool {
+		var resp cachedUser
+		err := c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1")), &resp)
+		return err == nil && resp.User.Name == "Eve"
+	}, time.Second, time.Millisecond)
+}
+
+func (s *SuiteNormalizedCache) TestErrorsAreNotCached() {
+	c := s.client([]gql.ClientOption{gql.WithNormalizedCache(gql.NewNormalizedCache())},
+		`{"data": {"user": {"__typename": "User", "id": "1", "name": "Bob"}}, "errors": [{"message": "partial"}]}`,
+		`{"data": {"user": {"__typename": "User", "id": "1", "name": "Bob"}}}`,
+	)
+
+	var resp cachedUser
+	s.EqualError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1")), &resp), "graphql: partial")
+	s.Equal("Bob", resp.User.Name)
+	s.NoError(c.Do(gql.NewRequest(userQuery, gql.WithVar("id", "1")), &resp))
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 2)
+}
+
+func (s *SuiteNormalizedCache) TestFragments() {
+	query := `query ($id: ID!) {
+		node(id: $id) {
+			__typename
+			id
+			...UserFields
+			... on Post { title }
+		}
+	}
+	fragment UserFields on User { name }`
+	body := `{"data": {"node": {"__typename": "User", "id": "1", "name": "Bob"}}}`
+
+	// Without a schema, fragments with other type conditions cannot be matched.
+	c := s.client([]gql.ClientOption{gql.WithNormalizedCache(gql.NewNormalizedCache())}, body, body)
+	for i := 0; i < 2; i++ {
+		s.Require().NoError(c.Do(gql.NewRequest(query, gql.WithVar("id", "1")), nil))
+	}
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 2)
+
+	// With a schema, they are matched against the possible types.
+	c = s.client([]gql.ClientOption{gql.WithNormalizedCache(gql.NewNormalizedCache()), gql.WithSchema(cacheSchema)}, body)
+	for i := 0; i < 2; i++ {
+		var resp struct {
+			Node struct {
+				Typename string `json:"__typename"`
+				Name     string
+				Title    *string
+			}
+		}
+		s.Require().NoError(c.Do(gql.NewRequest(query, gql.WithVar("id", "1")), &resp))
+		s.Equal("User", resp.Node.Typename)
+		s.Equal("Bob", resp.Node.Name)
+		s.Nil(resp.Node.Title)
+	}
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 1)
+}
+
+func (s *SuiteNormalizedCache) TestKeyFields() {
+	cache := gql.NewNormalizedCache(gql.WithKeyFields("Book", "isbn"))
+	c := s.client([]gql.ClientOption{gql.WithNormalizedCache(cache)},
+		`{"data": {"book": {"__typename": "Book", "isbn": "123", "title": "Go"}}}`,
+		`{"data": {"rename": {"__typename": "Book", "isbn": "123", "title": "GraphQL"}}}`,
+	)
+	query := `{ book(isbn: "123") { __typename isbn title } }`
+	s.Require().NoError(c.Do(gql.NewRequest(query), nil))
+	s.Require().NoError(c.Do(gql.NewRequest(`mutation { rename: updateBook { __typename isbn title } }`), nil))
+
+	var resp struct {
+		Book struct {
+			Title string
+		}
+	}
+	s.Require().NoError(c.Do(gql.NewRequest(query), &resp))
+	s.Equal("GraphQL", resp.Book.Title)
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 2)
+}
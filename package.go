@@ -6,10 +6,38 @@
 //      // Optionally supply options:
 //      // Set default headers.
 //      gqlclient.WithDefaultHeader("Authorization", "Bearer " + token),
+//      // Set default headers from the Context of every request, like a tenant id.
+//      gqlclient.WithDefaultHeaderFunc(gqlclient.ContextHeader("X-Tenant-Id", tenantKey{})),
 //      // Use a custom http.Client.
 //      gqlclient.WithHTTPClient(customClient),
 //      // Use another request builder (default: gqlclient.JSONRequestBuilder).
 //      gqlclient.WithRequestBuilder(gqlclient.MultipartRequestBuilder),
+//      // Use another json implementation (default: gqlclient.JSONCodec).
+//      gqlclient.WithCodec(customCodec),
+//      // Decompress responses with more content codings than gzip and deflate, and compress large
+//      // request bodies.
+//      gqlclient.WithEncoding("br", brotliEncoding),
+//      gqlclient.WithRequestCompression("gzip", gqlclient.GzipEncoding, 1024),
+//      // Validate queries against a schema before sending them.
+//      gqlclient.WithSchema(schema),
+//      // Send queries minified, in a normalized form (see gqlclient.NormalizeQuery).
+//      gqlclient.WithQueryNormalization(),
+//      // Send only the ids of trusted documents from a manifest (see gqlclient-gen manifest).
+//      gqlclient.WithTrustedDocuments(manifest, gqlclient.SendDocumentID),
+//      // Answer queries from a cache of the entities in responses, by __typename and id.
+//      gqlclient.WithNormalizedCache(gqlclient.NewNormalizedCache()),
+//      // Cache the response bodies of queries for a minute, in memory or on disk.
+//      gqlclient.WithResponseCache(gqlclient.NewLRUCacheStore(1000), time.Minute, "Accept-Language"),
+//      // Send identical concurrent queries only once, and share the response.
+//      gqlclient.WithDeduplication(),
+//      // Authenticate with OAuth 2.0 client credentials, refreshing the token before it expires.
+//      gqlclient.WithTokenSource(&gqlclient.ClientCredentials{TokenURL: tokenURL, ClientID: id, ClientSecret: secret}, time.Minute),
+//      // Sign the final body and headers, with AWS Signature Version 4 or an HMAC.
+//      gqlclient.WithSigner(&gqlclient.SigV4Signer{AccessKeyID: id, SecretAccessKey: secret, Region: region, Service: "appsync"}),
+//      // Cache the parsed documents of the 5000 most recently used queries (default: 1000).
+//      gqlclient.WithQueryCacheSize(5000),
+//      // Debug mode: check that response objects can hold the selected fields.
+//      gqlclient.WithShapeCheck(),
 //  )
 //
 // Make a request
@@ -26,8 +54,13 @@
 //      gqlclient.WithVar("key", "value"),
 //      // Set header fields.
 //      gqlclient.WithHeader("Cache-Control", "no-cache"),
+//      gqlclient.WithHeaderValues("Accept-Language", "nl", "en"),
 //      // Pass a Context for the request (default: context.Background()).
 //      gqlclient.WithContext(ctx),
+//      // Always send the query when the client has a normalized cache (default: gqlclient.CacheFirst).
+//      gqlclient.WithCachePolicy(gqlclient.NetworkOnly),
+//      // Fail on fields that are unknown or missing in the response data.
+//      gqlclient.WithDecodeOptions(gqlclient.DecodeOptions{DisallowUnknownFields: true, Strict: true}),
 //  )
 //
 // Do the request and capture the response.
@@ -40,6 +73,54 @@
 //  }
 //  err := client.Do(req, &resp)
 //
+// Or generate the query from the response struct, using graphql tags for arguments.
+//  var q struct {
+//      Item struct {
+//          Field1 string
+//      } `graphql:"item(id: $key)"`
+//  }
+//  req, err := gqlclient.NewQuery(&q, gqlclient.WithTypedVar("key", "String!", "value"))
+//  err = client.Do(req, &q)
+//
+//  // Or load named operations from .graphql files, for example embedded with go:embed.
+//  docs, err := gqlclient.LoadDocuments(files)
+//  req, err := docs.Request("GetItem", gqlclient.WithVar("key", "value"))
+//
+//  // Or get the undecoded data, errors and extensions to forward them.
+//  raw, err := client.DoRaw(req)
+//
+//  // Or decode the elements of a large list one at a time.
+//  err := client.DoStream(req, "data.items", func(i int, elem gqlclient.Decoder) error {
+//      var item Item
+//      return elem.Decode(&item)
+//  })
+//
+//  // Or iterate over the nodes of a Relay connection, requesting the next page when needed.
+//  p := client.Paginate(req, "data.items")
+//  for p.Next() {
+//      var item Item
+//      err := p.Decode(&item)
+//  }
+//  err := p.Err()
+//
+//  // Or iterate over the items of other paged lists, for example with offset and limit variables.
+//  it := client.Pages(req,
+//      func() interface{} { return new(ItemsPage) },
+//      func(page interface{}) interface{} { return page.(*ItemsPage).Items.Nodes },
+//      gqlclient.WithOffsetPages("offset", 100))
+//  for it.Next() {
+//      item := it.Item().(Item)
+//  }
+//  err := it.Err()
+//
+// Introspect the schema of the endpoint
+//  schema, err := client.Introspect(ctx)
+//
+// Render a schema as SDL or introspection json, or load it from files
+//  sdl := gqlclient.FormatSchemaSDL(schema)
+//  schema, err := gqlclient.LoadSchemaSDL("schema.graphql")
+//  schema, err := gqlclient.LoadSchemaJSON("schema.json")
+//
 // Inspect the returned GraphQL errors
 //  var gqlerrs gqlclient.ErrorList
 //  if errors.As(err, &gqlerrs) {
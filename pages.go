@@ -0,0 +1,293 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// PageItemsFunc returns the slice of items of a decoded page.
+type PageItemsFunc func(page interface{}) interface{}
+
+// NextPageFunc returns the variables of the request of the next page, given a decoded page and the
+// variables of its request, or false when the page is the last page. The variables can be modified.
+type NextPageFunc func(page interface{}, variables map[string]interface{}) (map[string]interface{}, bool)
+
+// PagesOption are functions that are passed into Client.Pages to modify the PageIterator.
+type PagesOption func(*PageIterator)
+
+// WithNextPage requests the pages after the first page with the variables returned by next, for
+// lists that are paged with a cursor, page number or other variable.
+//  WithNextPage(func(page interface{}, variables map[string]interface{}) (map[string]interface{}, bool) {
+//      resp := page.(*SearchResponse)
+//      variables["page"] = resp.Search.Page + 1
+//      return variables, resp.Search.Page < resp.Search.LastPage
+//  })
+func WithNextPage(next NextPageFunc) PagesOption {
+	return func(it *PageIterator) {
+		it.next = next
+	}
+}
+
+// WithOffsetPages requests the pages after the first page by increasing the offset variable with the
+// number of items of the page, starting at the value of the variable in the Request. A page with
+// fewer items than the limit is the last page. The limit must be positive.
+//  WithOffsetPages("offset", 100)
+func WithOffsetPages(variable string, limit int) PagesOption {
+	return func(it *PageIterator) {
+		it.offset = &offsetPages{variable: variable, limit: limit}
+	}
+}
+
+// WithPrefetch requests up to the given number of pages concurrently ahead of the iteration, when
+// the total number of items is known. The total function returns the total number of items from
+// the first page, or -1 when it is unknown. The pages are still returned in order. Prefetching
+// requires WithOffsetPages.
+//  WithPrefetch(4, func(page interface{}) int {
+//      return page.(*UsersResponse).Users.TotalCount
+//  })
+func WithPrefetch(pages int, total func(page interface{}) int) PagesOption {
+	return func(it *PageIterator) {
+		it.prefetch = pages
+		it.total = total
+	}
+}
+
+// PageIterator iterates over the items of a paged list that is not a Relay connection, requesting
+// the pages as they are needed. Use Next to iterate item by item, or NextPage to iterate page by
+// page.
+type PageIterator struct {
+	client   *Client
+	req      *Request
+	newPage  func() interface{}
+	items    PageItemsFunc
+	next     NextPageFunc
+	offset   *offsetPages
+	prefetch int
+	total    func(page interface{}) int
+
+	started     bool
+	done        bool
+	prefetching bool
+	variables   map[string]interface{}
+	offsets     []int
+	pending     []chan pageResult
+	page        interface{}
+	pageItems   reflect.Value
+	index       int
+	err         error
+}
+
+// offsetPages is the configuration of WithOffsetPages.
+type offsetPages struct {
+	variable string
+	limit    int
+	value    int
+}
+
+// pageResult is the result of a prefetched page.
+type pageResult struct {
+	page interface{}
+	err  error
+}
+
+// Pages returns a PageIterator for a paged list. Every page is requested with Do, and decoded into
+// a new value returned by newPage. The items function returns the items of a decoded page. Without
+// WithNextPage or WithOffsetPages only the page of the Request is requested.
+//  req := NewRequest(`query ($offset: Int!) {
+//      users(offset: $offset, limit: 100) { totalCount items { id } }
+//  }`, WithVar("offset", 0))
+//  it := client.Pages(req,
+//      func() interface{} { return new(UsersResponse) },
+//      func(page interface{}) interface{} { return page.(*UsersResponse).Users.Items },
+//      WithOffsetPages("offset", 100))
+//  for it.Next() {
+//      user := it.Item().(User)
+//  }
+//  if err := it.Err(); err != nil {
+//      return err
+//  }
+func (c *Client) Pages(req *Request, newPage func() interface{}, items PageItemsFunc, opts ...PagesOption) *PageIterator {
+	it := &PageIterator{
+		client:    c,
+		req:       req,
+		newPage:   newPage,
+		items:     items,
+		variables: req.Variables,
+	}
+	for _, optionFunc := range opts {
+		optionFunc(it)
+	}
+	if it.offset != nil {
+		var err error
+		if it.offset.value, err = intValue(req.Variables[it.offset.variable]); err != nil {
+			it.err = fmt.Errorf("offset variable $%s: %w", it.offset.variable, err)
+		}
+		if it.offset.limit <= 0 {
+			it.err = fmt.Errorf("limit of the offset pages is %d, not positive", it.offset.limit)
+		}
+	}
+	if it.prefetch > 0 && it.offset == nil {
+		it.err = errors.New("prefetching requires WithOffsetPages")
+	}
+	return it
+}
+
+// Next advances to the next item, requesting the next page when the items of the current page are
+// done. It returns false when there are no more items or when an error occurred.
+func (it *PageIterator) Next() bool {
+	if it.page != nil && it.index+1 < it.pageItems.Len() {
+		it.index++
+		return true
+	}
+	for it.NextPage() {
+		if it.pageItems.Len() > 0 {
+			it.index = 0
+			return true
+		}
+	}
+	return false
+}
+
+// NextPage advances to the next page, skipping the items of the current page that were not
+// iterated with Next. It returns false when there are no more pages or when an error occurred.
+// Pages that were prefetched when the iteration stops are still requested, but not returned.
+func (it *PageIterator) NextPage() bool {
+	if it.err != nil || it.done {
+		it.page = nil
+		return false
+	}
+	if err := it.req.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	var result pageResult
+	if len(it.pending) > 0 {
+		result = <-it.pending[0]
+		it.pending = it.pending[1:]
+	} else {
+		result.page, result.err = it.fetch(it.variables)
+	}
+	if result.err != nil {
+		it.err = result.err
+		return false
+	}
+	items := reflect.ValueOf(it.items(result.page))
+	if items.Kind() != reflect.Slice {
+		it.err = fmt.Errorf("items of the page are a %s, not a slice", items.Kind())
+		return false
+	}
+	it.page, it.pageItems, it.index = result.page, items, -1
+
+	// Find the variables of the next page.
+	if !it.started {
+		it.startPrefetch(result.page, items.Len())
+	}
+	switch {
+	case it.prefetching:
+		it.schedule()
+		it.done = len(it.pending) == 0
+	case it.offset != nil:
+		it.offset.value += items.Len()
+		it.variables = withVariable(it.req, it.offset.variable, it.offset.value).Variables
+		it.done = items.Len() < it.offset.limit
+	case it.next != nil:
+		variables := make(map[string]interface{}, len(it.variables))
+		for name, value := range it.variables {
+			variables[name] = value
+		}
+		var ok bool
+		it.variables, ok = it.next(result.page, variables)
+		it.done = !ok
+	default:
+		it.done = true
+	}
+	it.started = true
+	return true
+}
+
+// startPrefetch computes the offsets of the pages after the first page when prefetching is enabled
+// and the total number of items is known.
+func (it *PageIterator) startPrefetch(page interface{}, n int) {
+	if it.prefetch <= 0 || n < it.offset.limit {
+		return
+	}
+	total := it.total(page)
+	if total < 0 {
+		return
+	}
+	it.prefetching = true
+	for offset := it.offset.value + n; offset < total; offset += it.offset.limit {
+		it.offsets = append(it.offsets, offset)
+	}
+}
+
+// schedule starts requesting the next offsets until the number of pending pages is the number of
+// pages to prefetch.
+func (it *PageIterator) schedule() {
+	for len(it.pending) < it.prefetch && len(it.offsets) > 0 {
+		variables := withVariable(it.req, it.offset.variable, it.offsets[0]).Variables
+		it.offsets = it.offsets[1:]
+
+		result := make(chan pageResult, 1)
+		it.pending = append(it.pending, result)
+		go func() {
+			page, err := it.fetch(variables)
+			result <- pageResult{page: page, err: err}
+		}()
+	}
+}
+
+// fetch requests the page with the variables.
+func (it *PageIterator) fetch(variables map[string]interface{}) (interface{}, error) {
+	req := *it.req
+	req.Variables = variables
+	page := it.newPage()
+	if err := it.client.Do(&req, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// Item returns the current item.
+func (it *PageIterator) Item() interface{} {
+	if it.page == nil || it.index < 0 {
+		return nil
+	}
+	return it.pageItems.Index(it.index).Interface()
+}
+
+// Page returns the current page, as returned by newPage.
+func (it *PageIterator) Page() interface{} {
+	return it.page
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *PageIterator) Err() error {
+	return it.err
+}
+
+// intValue returns the integer value of a variable, which is 0 when the variable is not set.
+func intValue(v interface{}) (int, error) {
+	if n, ok := v.(json.Number); ok {
+		i, err := n.Int64()
+		return int(i), err
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return 0, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		if f := rv.Float(); f == math.Trunc(f) {
+			return int(f), nil
+		}
+	}
+	return 0, fmt.Errorf("%v is not an integer", v)
+}
@@ -0,0 +1,212 @@
+package gqlclient_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuitePages struct {
+	suite.Suite
+	httpClient *mocks.HTTPClient
+	mu         sync.Mutex
+	offsets    []int
+}
+
+func TestSuitePages(t *testing.T) {
+	s := SuitePages{}
+	suite.Run(t, &s)
+}
+
+type usersPage struct {
+	Users struct {
+		TotalCount int
+		Items      []int
+	}
+}
+
+func newUsersPage() interface{} {
+	return new(usersPage)
+}
+
+func usersPageItems(page interface{}) interface{} {
+	return page.(*usersPage).Users.Items
+}
+
+// client returns a client that responds with the items from the offset variable to the offset plus
+// the limit variable, of a list with the total number of items.
+func (s *SuitePages) client(total int) *gql.Client {
+	s.offsets = nil
+	s.httpClient = new(mocks.HTTPClient)
+	s.httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) *http.Response {
+			var body struct {
+				Variables struct {
+					Offset int
+					Limit  int
+				}
+			}
+			s.Require().NoError(json.NewDecoder(req.Body).Decode(&body))
+			s.mu.Lock()
+			s.offsets = append(s.offsets, body.Variables.Offset)
+			s.mu.Unlock()
+
+			items := []string{}
+			for i := body.Variables.Offset; i < total && i < body.Variables.Offset+body.Variables.Limit; i++ {
+				items = append(items, fmt.Sprint(i))
+			}
+			return &http.Response{
+				Body: ioutil.NopCloser(strings.NewReader(fmt.Sprintf(
+					`{"data": {"users": {"totalCount": %d, "items": [%s]}}}`, total, strings.Join(items, ",")))),
+				StatusCode: http.StatusOK,
+			}
+		}, nil)
+	return gql.NewClient("test", gql.WithHTTPClient(s.httpClient))
+}
+
+func (s *SuitePages) request() *gql.Request {
+	return gql.NewRequest(`query ($offset: Int!, $limit: Int!) {
+		users(offset: $offset, limit: $limit) { totalCount items }
+	}`, gql.WithVar("offset", 0), gql.WithVar("limit", 2))
+}
+
+func (s *SuitePages) TestOffsetPages() {
+	c := s.client(5)
+	it := c.Pages(s.request(), newUsersPage, usersPageItems, gql.WithOffsetPages("offset", 2))
+	var items []int
+	for it.Next() {
+		items = append(items, it.Item().(int))
+	}
+	s.NoError(it.Err())
+	s.Equal([]int{0, 1, 2, 3, 4}, items)
+	s.Equal([]int{0, 2, 4}, s.offsets)
+
+	// The last page is full, and the next page is empty.
+	c = s.client(4)
+	it = c.Pages(s.request(), newUsersPage, usersPageItems, gql.WithOffsetPages("offset", 2))
+	var pages int
+	for it.NextPage() {
+		s.IsType(&usersPage{}, it.Page())
+		pages++
+	}
+	s.NoError(it.Err())
+	s.Equal(3, pages)
+	s.Equal([]int{0, 2, 4}, s.offsets)
+
+	// The offset starts at the value of the variable, which can be of any integer type.
+	for _, offset := range []interface{}{uint16(2), int8(2), json.Number("2"), 2.0} {
+		c = s.client(5)
+		req := s.request()
+		req.Variables["offset"] = offset
+		it = c.Pages(req, newUsersPage, usersPageItems, gql.WithOffsetPages("offset", 2))
+		for it.Next() {
+		}
+		s.NoError(it.Err())
+		s.Equal([]int{2, 4}, s.offsets)
+	}
+}
+
+func (s *SuitePages) TestPrefetch() {
+	c := s.client(7)
+	it := c.Pages(s.request(), newUsersPage, usersPageItems,
+		gql.WithOffsetPages("offset", 2),
+		gql.WithPrefetch(2, func(page interface{}) int {
+			return page.(*usersPage).Users.TotalCount
+		}))
+	var items []int
+	for it.Next() {
+		items = append(items, it.Item().(int))
+	}
+	s.NoError(it.Err())
+	s.Equal([]int{0, 1, 2, 3, 4, 5, 6}, items)
+	sort.Ints(s.offsets)
+	s.Equal([]int{0, 2, 4, 6}, s.offsets)
+
+	// Without a total the pages are requested one by one.
+	c = s.client(3)
+	it = c.Pages(s.request(), newUsersPage, usersPageItems,
+		gql.WithOffsetPages("offset", 2),
+		gql.WithPrefetch(2, func(page interface{}) int {
+			return -1
+		}))
+	items = nil
+	for it.Next() {
+		items = append(items, it.Item().(int))
+	}
+	s.NoError(it.Err())
+	s.Equal([]int{0, 1, 2}, items)
+	s.Equal([]int{0, 2}, s.offsets)
+}
+
+func (s *SuitePages) TestNextPage() {
+	c := s.client(5)
+	it := c.Pages(s.request(), newUsersPage, usersPageItems,
+		gql.WithNextPage(func(page interface{}, variables map[string]interface{}) (map[string]interface{}, bool) {
+			variables["offset"] = variables["offset"].(int) + 2
+			return variables, variables["offset"].(int) < page.(*usersPage).Users.TotalCount
+		}))
+	var items []int
+	for it.Next() {
+		items = append(items, it.Item().(int))
+	}
+	s.NoError(it.Err())
+	s.Equal([]int{0, 1, 2, 3, 4}, items)
+	s.Equal([]int{0, 2, 4}, s.offsets)
+
+	// Without a next page only the first page is requested.
+	it = c.Pages(s.request(), newUsersPage, usersPageItems)
+	s.True(it.NextPage())
+	s.False(it.NextPage())
+	s.Nil(it.Page())
+}
+
+func (s *SuitePages) TestErrors() {
+	c := s.client(5)
+	it := c.Pages(s.request(), newUsersPage, usersPageItems, gql.WithPrefetch(2, nil))
+	s.False(it.Next())
+	s.EqualError(it.Err(), "prefetching requires WithOffsetPages")
+
+	it = c.Pages(s.request(), newUsersPage, usersPageItems, gql.WithOffsetPages("offset", 0))
+	s.False(it.Next())
+	s.EqualError(it.Err(), "limit of the offset pages is 0, not positive")
+	s.httpClient.AssertNotCalled(s.T(), "Do", mock.Anything)
+
+	req := s.request()
+	req.Variables["offset"] = 2.5
+	it = c.Pages(req, newUsersPage, usersPageItems, gql.WithOffsetPages("offset", 2))
+	s.False(it.Next())
+	s.EqualError(it.Err(), "offset variable $offset: 2.5 is not an integer")
+	s.httpClient.AssertNotCalled(s.T(), "Do", mock.Anything)
+
+	it = c.Pages(s.request(), newUsersPage, func(page interface{}) interface{} {
+		return page.(*usersPage).Users.TotalCount
+	})
+	s.False(it.Next())
+	s.EqualError(it.Err(), "items of the page are a int, not a slice")
+
+	s.httpClient = new(mocks.HTTPClient)
+	s.httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(func(*http.Request) *http.Response {
+			return &http.Response{
+				Body:       ioutil.NopCloser(strings.NewReader(`{"errors": [{"message": "not allowed"}]}`)),
+				StatusCode: http.StatusOK,
+			}
+		}, nil)
+	c = gql.NewClient("test", gql.WithHTTPClient(s.httpClient))
+	it = c.Pages(s.request(), newUsersPage, usersPageItems, gql.WithOffsetPages("offset", 2))
+	s.False(it.Next())
+	s.EqualError(it.Err(), "graphql: not allowed")
+}
@@ -0,0 +1,274 @@
+package gqlclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PaginatorOption are functions that are passed into Client.Paginate to modify the Paginator.
+type PaginatorOption func(*Paginator)
+
+// WithBackwardPagination pages backward through a connection, using the $before variable with the
+// startCursor of the previous page while it has a previous page. Use $last for the page size. The
+// nodes of a page are returned in the order of the page.
+//  client.Paginate(req, "data.user.posts", WithBackwardPagination())
+func WithBackwardPagination() PaginatorOption {
+	return func(p *Paginator) {
+		p.backward = true
+	}
+}
+
+// Paginator iterates over the nodes of a Relay connection, requesting the pages of the connection
+// as they are needed. Use Next to iterate node by node, or NextPage to iterate page by page.
+type Paginator struct {
+	client   *Client
+	req      *Request
+	path     []string
+	backward bool
+
+	started bool
+	done    bool
+	cursor  *string
+	page    *connectionPage
+	index   int
+	err     error
+}
+
+// connectionPage is a page of a Relay connection.
+type connectionPage struct {
+	raw      json.RawMessage
+	Edges    []json.RawMessage `json:"edges"`
+	Nodes    []json.RawMessage `json:"nodes"`
+	PageInfo struct {
+		HasNextPage     bool    `json:"hasNextPage"`
+		HasPreviousPage bool    `json:"hasPreviousPage"`
+		StartCursor     *string `json:"startCursor"`
+		EndCursor       *string `json:"endCursor"`
+	} `json:"pageInfo"`
+}
+
+// connectionEdge is an edge of a Relay connection.
+type connectionEdge struct {
+	Cursor string          `json:"cursor"`
+	Node   json.RawMessage `json:"node"`
+}
+
+// Paginate returns a Paginator for the Relay connection at the path in the response of the Request.
+// The path is a dot separated list of field names starting at the root of the response, like for
+// DoStream. The query must use the $after variable for the cursor, and select the endCursor and
+// hasNextPage fields of the pageInfo, and the edges or nodes of the connection. Every page is
+// requested with Do, using the Context of the Request.
+//  req := NewRequest(`query ($first: Int!, $after: String) {
+//      orders(first: $first, after: $after) {
+//          edges { cursor node { id } }
+//          pageInfo { endCursor hasNextPage }
+//      }
+//  }`, WithVar("first", 100))
+//  p := client.Paginate(req, "data.orders")
+//  for p.Next() {
+//      var order Order
+//      if err := p.Decode(&order); err != nil {
+//          return err
+//      }
+//  }
+//  if err := p.Err(); err != nil {
+//      return err
+//  }
+func (c *Client) Paginate(req *Request, path string, opts ...PaginatorOption) *Paginator {
+	p := &Paginator{
+		client: c,
+		req:    req,
+		path:   strings.Split(path, "."),
+	}
+	for _, optionFunc := range opts {
+		optionFunc(p)
+	}
+	return p
+}
+
+// Next advances to the next node, requesting the next page when the nodes of the current page are
+// done. It returns false when there are no more nodes or when an error occurred.
+func (p *Paginator) Next() bool {
+	if p.page != nil && p.index+1 < p.page.len() {
+		p.index++
+		return true
+	}
+	for p.NextPage() {
+		if p.page.len() > 0 {
+			p.index = 0
+			return true
+		}
+	}
+	return false
+}
+
+// NextPage advances to the next page, skipping the nodes of the current page that were not
+// iterated with Next. It returns false when there are no more pages or when an error occurred.
+func (p *Paginator) NextPage() bool {
+	if p.err != nil || p.done {
+		p.page = nil
+		return false
+	}
+	if len(p.path) == 0 || p.path[0] != "data" {
+		p.err = fmt.Errorf("path %s does not start at data", strings.Join(p.path, "."))
+		return false
+	}
+	if err := p.req.ctx.Err(); err != nil {
+		p.err = err
+		return false
+	}
+
+	page, err := p.fetch()
+	if err != nil {
+		p.err = err
+		return false
+	}
+	p.page, p.index, p.started = page, -1, true
+
+	// Find the cursor of the next page.
+	hasMore, cursor := page.PageInfo.HasNextPage, page.PageInfo.EndCursor
+	if p.backward {
+		hasMore, cursor = page.PageInfo.HasPreviousPage, page.PageInfo.StartCursor
+	}
+	switch {
+	case !hasMore:
+		p.done = true
+	case cursor == nil:
+		p.err = errors.New("page has no cursor for the next page")
+		p.done = true
+	case p.cursor != nil && *cursor == *p.cursor:
+		p.err = errors.New("cursor of the next page did not change")
+		p.done = true
+	default:
+		p.cursor = cursor
+	}
+	return true
+}
+
+// fetch requests the page at the cursor.
+func (p *Paginator) fetch() (*connectionPage, error) {
+	req := p.req
+	if p.started {
+		variable := "after"
+		if p.backward {
+			variable = "before"
+		}
+		req = withVariable(req, variable, *p.cursor)
+	}
+
+	var data json.RawMessage
+	if err := p.client.Do(req, &data); err != nil {
+		return nil, err
+	}
+
+	// Find the connection in the data.
+	raw := data
+	for i := 1; i < len(p.path); i++ {
+		var object map[string]json.RawMessage
+		if err := p.client.codec.NewDecoder(bytes.NewReader(raw)).Decode(&object); err != nil || object == nil {
+			return nil, fmt.Errorf("no object at %s", strings.Join(p.path[:i], "."))
+		}
+		raw = object[p.path[i]]
+	}
+
+	page := &connectionPage{raw: raw}
+	if err := p.client.codec.NewDecoder(bytes.NewReader(raw)).Decode(page); err != nil || bytes.Equal(raw, []byte("null")) || len(raw) == 0 {
+		return nil, fmt.Errorf("no connection at %s", strings.Join(p.path, "."))
+	}
+	return page, nil
+}
+
+// withVariable returns a copy of the Request with the variable set.
+func withVariable(req *Request, name string, value interface{}) *Request {
+	r := *req
+	r.Variables = make(map[string]interface{}, len(req.Variables)+1)
+	for k, v := range req.Variables {
+		r.Variables[k] = v
+	}
+	r.Variables[name] = value
+	return &r
+}
+
+// len returns the number of nodes of the page.
+func (c *connectionPage) len() int {
+	if c.Edges != nil {
+		return len(c.Edges)
+	}
+	return len(c.Nodes)
+}
+
+// Decode decodes the current node into v.
+func (p *Paginator) Decode(v interface{}) error {
+	if p.page == nil || p.index < 0 {
+		return errors.New("Decode called without a node")
+	}
+	if p.page.Edges == nil {
+		return p.decode(p.page.Nodes[p.index], v)
+	}
+	edge, err := p.edge()
+	if err != nil {
+		return err
+	}
+	return p.decode(edge.Node, v)
+}
+
+// DecodeEdge decodes the edge of the current node into v, for example to get the cursor or other
+// fields of the edge. The query must select the edges of the connection.
+func (p *Paginator) DecodeEdge(v interface{}) error {
+	if p.page == nil || p.index < 0 {
+		return errors.New("DecodeEdge called without a node")
+	}
+	if p.page.Edges == nil {
+		return errors.New("connection has no edges")
+	}
+	return p.decode(p.page.Edges[p.index], v)
+}
+
+// Cursor returns the cursor of the edge of the current node, which is empty when the query does not
+// select the edges of the connection.
+func (p *Paginator) Cursor() string {
+	if p.page == nil || p.index < 0 || p.page.Edges == nil {
+		return ""
+	}
+	edge, err := p.edge()
+	if err != nil {
+		return ""
+	}
+	return edge.Cursor
+}
+
+// DecodePage decodes the connection of the current page into v.
+//  for p.NextPage() {
+//      var page OrderConnection
+//      if err := p.DecodePage(&page); err != nil {
+//          return err
+//      }
+//  }
+func (p *Paginator) DecodePage(v interface{}) error {
+	if p.page == nil {
+		return errors.New("DecodePage called without a page")
+	}
+	return p.decode(p.page.raw, v)
+}
+
+// Err returns the error that stopped the pagination, if any.
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+// edge decodes the edge of the current node.
+func (p *Paginator) edge() (*connectionEdge, error) {
+	var edge connectionEdge
+	if err := p.decode(p.page.Edges[p.index], &edge); err != nil {
+		return nil, err
+	}
+	return &edge, nil
+}
+
+// decode decodes a part of the response with the DecodeOptions of the Request.
+func (p *Paginator) decode(raw json.RawMessage, v interface{}) error {
+	return p.client.decodeOptionsFor(p.req).decodeData(p.client.codec, raw, v)
+}
@@ -0,0 +1,181 @@
+package gqlclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuitePagination struct {
+	suite.Suite
+	httpClient *mocks.HTTPClient
+	cursors    []interface{}
+}
+
+func TestSuitePagination(t *testing.T) {
+	s := SuitePagination{}
+	suite.Run(t, &s)
+}
+
+// client returns a client that responds with the pages in order, and records the cursor variable of
+// every request.
+func (s *SuitePagination) client(variable string, pages ...string) *gql.Client {
+	s.cursors = nil
+	s.httpClient = new(mocks.HTTPClient)
+	for _, page := range pages {
+		page := page
+		s.httpClient.
+			On("Do", mock.AnythingOfType("*http.Request")).
+			Return(func(req *http.Request) *http.Response {
+				var body struct {
+					Variables map[string]interface{}
+				}
+				s.Require().NoError(json.NewDecoder(req.Body).Decode(&body))
+				s.cursors = append(s.cursors, body.Variables[variable])
+				return &http.Response{
+					Body:       ioutil.NopCloser(strings.NewReader(page)),
+					StatusCode: http.StatusOK,
+				}
+			}, nil).
+			Once()
+	}
+	return gql.NewClient("test", gql.WithHTTPClient(s.httpClient))
+}
+
+func (s *SuitePagination) TestNodes() {
+	c := s.client("after",
+		`{"data": {"user": {"friends": {
+			"edges": [{"cursor": "a", "node": {"name": "Alice"}}, {"cursor": "b", "node": {"name": "Bob"}}],
+			"pageInfo": {"endCursor": "b", "hasNextPage": true}}}}}`,
+		`{"data": {"user": {"friends": {
+			"edges": [],
+			"pageInfo": {"endCursor": "b", "hasNextPage": true}}}}}`,
+		`{"data": {"user": {"friends": {
+			"edges": [{"cursor": "c", "node": {"name": "Carol"}}],
+			"pageInfo": {"endCursor": "c", "hasNextPage": false}}}}}`,
+	)
+	req := gql.NewRequest(`query ($first: Int!, $after: String) {
+		user(id: 1) { friends(first: $first, after: $after) { edges { cursor node { name } } pageInfo { endCursor hasNextPage } } }
+	}`, gql.WithVar("first", 2))
+
+	p := c.Paginate(req, "data.user.friends")
+	var names, cursors []string
+	for p.Next() {
+		var node struct {
+			Name string
+		}
+		s.Require().NoError(p.Decode(&node))
+		names = append(names, node.Name)
+		cursors = append(cursors, p.Cursor())
+	}
+	// The cursor of the empty page did not change.
+	s.EqualError(p.Err(), "cursor of the next page did not change")
+	s.Equal([]string{"Alice", "Bob"}, names)
+	s.Equal([]string{"a", "b"}, cursors)
+	s.Equal([]interface{}{nil, "b"}, s.cursors)
+	s.Equal(map[string]interface{}{"first": 2}, req.Variables)
+}
+
+func (s *SuitePagination) TestPages() {
+	c := s.client("after",
+		`{"data": {"orders": {
+			"nodes": [{"id": "1"}, {"id": "2"}],
+			"pageInfo": {"endCursor": "2", "hasNextPage": true}}}}`,
+		`{"data": {"orders": {
+			"nodes": [{"id": "3"}],
+			"pageInfo": {"endCursor": "3", "hasNextPage": false}}}}`,
+	)
+	req := gql.NewRequest(`query ($after: String) {
+		orders(first: 2, after: $after) { nodes { id } pageInfo { endCursor hasNextPage } }
+	}`)
+
+	p := c.Paginate(req, "data.orders")
+	var pages [][]string
+	for p.NextPage() {
+		var page struct {
+			Nodes []struct {
+				ID string
+			}
+		}
+		s.Require().NoError(p.DecodePage(&page))
+		var ids []string
+		for _, node := range page.Nodes {
+			ids = append(ids, node.ID)
+		}
+		pages = append(pages, ids)
+	}
+	s.NoError(p.Err())
+	s.Equal([][]string{{"1", "2"}, {"3"}}, pages)
+	s.Equal([]interface{}{nil, "2"}, s.cursors)
+	s.False(p.Next())
+}
+
+func (s *SuitePagination) TestBackward() {
+	c := s.client("before",
+		`{"data": {"orders": {
+			"edges": [{"cursor": "c", "node": {"id": "3"}}, {"cursor": "d", "node": {"id": "4"}}],
+			"pageInfo": {"startCursor": "c", "hasPreviousPage": true}}}}`,
+		`{"data": {"orders": {
+			"edges": [{"cursor": "a", "node": {"id": "1"}}, {"cursor": "b", "node": {"id": "2"}}],
+			"pageInfo": {"startCursor": "a", "hasPreviousPage": false}}}}`,
+	)
+	req := gql.NewRequest(`query ($before: String) {
+		orders(last: 2, before: $before) { edges { cursor node { id } } pageInfo { startCursor hasPreviousPage } }
+	}`)
+
+	p := c.Paginate(req, "data.orders", gql.WithBackwardPagination())
+	var ids []string
+	for p.Next() {
+		var edge struct {
+			Cursor string
+			Node   struct {
+				ID string
+			}
+		}
+		s.Require().NoError(p.DecodeEdge(&edge))
+		s.Equal(edge.Cursor, p.Cursor())
+		ids = append(ids, edge.Node.ID)
+	}
+	s.NoError(p.Err())
+	s.Equal([]string{"3", "4", "1", "2"}, ids)
+	s.Equal([]interface{}{nil, "c"}, s.cursors)
+}
+
+func (s *SuitePagination) TestErrors() {
+	page := `{"data": {"orders": {
+		"nodes": [{"id": "1"}],
+		"pageInfo": {"endCursor": "1", "hasNextPage": true}}}}`
+	c := s.client("after", page, `{"errors": [{"message": "not allowed"}]}`)
+	p := c.Paginate(gql.NewRequest(`{ orders { nodes { id } } }`), "data.orders")
+	s.True(p.Next())
+	s.False(p.Next())
+	s.EqualError(p.Err(), "graphql: not allowed")
+
+	// The Context is checked before every page.
+	ctx, cancel := context.WithCancel(context.Background())
+	c = s.client("after", page, page)
+	p = c.Paginate(gql.NewRequest(`{ orders { nodes { id } } }`, gql.WithContext(ctx)), "data.orders")
+	s.True(p.Next())
+	cancel()
+	s.False(p.Next())
+	s.Equal(context.Canceled, p.Err())
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 1)
+
+	c = s.client("after", page)
+	p = c.Paginate(gql.NewRequest(`{ orders { nodes { id } } }`), "data.users")
+	s.False(p.Next())
+	s.EqualError(p.Err(), "no connection at data.users")
+
+	p = c.Paginate(gql.NewRequest(`{ orders { nodes { id } } }`), "orders")
+	s.False(p.Next())
+	s.EqualError(p.Err(), "path orders does not start at data")
+}
@@ -0,0 +1,134 @@
+package gqlclient
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// defaultQueryCacheSize is the number of queries in the query cache of a Client by default.
+const defaultQueryCacheSize = 1000
+
+// WithQueryCacheSize sets the number of queries of which the parsed document, the validation result
+// and the normalized form are cached, which is 1000 by default. The least recently used queries are
+// removed first.
+//  NewClient(endpoint, WithQueryCacheSize(5000))
+func WithQueryCacheSize(size int) ClientOption {
+	return func(client *Client) {
+		client.queries = newQueryCache(size)
+	}
+}
+
+// queryCache caches parsed queries for the features of a Client that need the document of a query,
+// and removes the least recently used queries when it is full.
+type queryCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newQueryCache(capacity int) *queryCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &queryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached query, and adds it to the cache when it is not cached yet.
+func (c *queryCache) get(query string) *cachedQuery {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[query]; ok {
+		c.order.MoveToFront(elem)
+		return elem.Value.(*cachedQuery)
+	}
+
+	q := &cachedQuery{query: query}
+	c.entries[query] = c.order.PushFront(q)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedQuery).query)
+	}
+	return q
+}
+
+// len returns the number of cached queries.
+func (c *queryCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// cachedQuery is a query in the queryCache. Every result is computed when it is first used. The
+// documents are shared, and must not be modified.
+type cachedQuery struct {
+	query string
+
+	parseOnce sync.Once
+	doc       *ast.QueryDocument
+	parseErr  error
+
+	normalizeOnce sync.Once
+	normalized    string
+
+	validateOnce sync.Once
+	validated    *ast.QueryDocument
+	errs         gqlerror.List
+
+	mu     sync.RWMutex
+	shapes map[reflect.Type]error
+}
+
+// document returns the parsed document of the query.
+func (q *cachedQuery) document() (*ast.QueryDocument, error) {
+	q.parseOnce.Do(func() {
+		q.doc, q.parseErr = parseQuery(q.query)
+	})
+	return q.doc, q.parseErr
+}
+
+// operation returns the operation of the query, or nil when the query cannot be parsed or does not
+// have a single operation.
+func (q *cachedQuery) operation() *ast.OperationDefinition {
+	doc, err := q.document()
+	if err != nil || len(doc.Operations) != 1 {
+		return nil
+	}
+	return doc.Operations[0]
+}
+
+// normalize returns the normalized form of the query, as returned by NormalizeQuery.
+func (q *cachedQuery) normalize() (string, error) {
+	doc, err := q.document()
+	if err != nil {
+		return "", err
+	}
+	q.normalizeOnce.Do(func() {
+		q.normalized = normalizeDocument(doc)
+	})
+	return q.normalized, nil
+}
+
+// validate returns the document of the query validated against the schema. The schema of a Client
+// does not change, so it is validated only once.
+func (q *cachedQuery) validate(schema *ast.Schema) (*ast.QueryDocument, error) {
+	q.validateOnce.Do(func() {
+		// Validation annotates the document, so it is parsed again.
+		q.validated, q.errs = gqlparser.LoadQuery(schema, q.query)
+	})
+	if len(q.errs) > 0 {
+		return nil, &ValidationError{Errors: q.errs}
+	}
+	return q.validated, nil
+}
@@ -0,0 +1,44 @@
+package gqlclient
+
+import (
+	"testing"
+)
+
+func TestQueryCache_Get(t *testing.T) {
+	c := newQueryCache(2)
+	a := c.get(`query B { b } query A { a }`)
+	c.get(`{ b }`)
+
+	// Getting a query makes it the most recently used query.
+	if c.get(`query B { b } query A { a }`) != a {
+		t.Fatal("query A was not cached")
+	}
+	c.get(`{ c }`)
+	if n := c.len(); n != 2 {
+		t.Fatalf("len() = %d, want 2", n)
+	}
+	if _, ok := c.entries[`{ b }`]; ok {
+		t.Error("least recently used query was not removed")
+	}
+
+	// Normalizing does not change the shared document.
+	normalized, err := a.normalize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "query A{a}query B{b}"; normalized != want {
+		t.Errorf("normalize() = %q, want %q", normalized, want)
+	}
+	doc, _ := a.document()
+	if doc.Operations[0].Name != "B" {
+		t.Error("normalize() sorted the operations of the shared document")
+	}
+	if a.operation() != nil {
+		t.Error("operation() of a query with two operations is not nil")
+	}
+
+	// Invalid queries are cached with their error.
+	if _, err := c.get(`{`).normalize(); err == nil {
+		t.Error("normalize() of an invalid query did not fail")
+	}
+}
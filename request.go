@@ -1,20 +1,34 @@
 package gqlclient
 
-import "context"
+import (
+	"context"
+	"net/http"
+	"time"
+)
 
 // Request is a GraphQL request.
 type Request struct {
-	ctx       context.Context        `json:"-"`
-	headers   map[string]string      `json:"-"`
-	Query     string                 `json:"query"`
-	Variables map[string]interface{} `json:"variables,omitempty"`
+	ctx         context.Context        `json:"-"`
+	headers     http.Header            `json:"-"`
+	headerFuncs []HeaderFunc           `json:"-"`
+	codec       Codec                  `json:"-"`
+	decode      *DecodeOptions         `json:"-"`
+	compression *requestCompression    `json:"-"`
+	varTypes    map[string]string      `json:"-"`
+	cachePolicy CachePolicy            `json:"-"`
+	Query       string                 `json:"query,omitempty"`
+	Variables   map[string]interface{} `json:"variables,omitempty"`
+	// DocumentID is the id of a trusted document, which is sent instead of the query.
+	DocumentID string `json:"documentId,omitempty"`
+	// Extensions are sent to the server along with the query, like the persistedQuery extension.
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
 }
 
 // NewRequest makes a new Request with the specified string.
 func NewRequest(query string, opts ...RequestOption) *Request {
 	req := &Request{
 		ctx:       context.Background(),
-		headers:   make(map[string]string),
+		headers:   make(http.Header),
 		Query:     query,
 		Variables: make(map[string]interface{}),
 	}
@@ -24,6 +38,14 @@ func NewRequest(query string, opts ...RequestOption) *Request {
 	return req
 }
 
+// getCodec returns the Codec that is used to encode the Request, which defaults to JSONCodec.
+func (r *Request) getCodec() Codec {
+	if r.codec == nil {
+		return JSONCodec
+	}
+	return r.codec
+}
+
 // RequestOption are functions that are passed into NewRequest to modify the Request.
 type RequestOption func(*Request)
 
@@ -35,11 +57,51 @@ func WithContext(ctx context.Context) RequestOption {
 	}
 }
 
+// backgroundTimeout is the timeout of the work that is done in the background for a Request, like
+// updating the normalized cache or refreshing a token.
+const backgroundTimeout = time.Minute
+
+// detachedContext keeps the values of a Context, without its deadline and cancellation.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
 // WithHeader sets an entry in the header of a Request to the specified value.
 //  NewRequest(query, WithHeader(key, value))
 func WithHeader(key string, value string) RequestOption {
 	return func(r *Request) {
-		r.headers[key] = value
+		r.headers.Set(key, value)
+	}
+}
+
+// WithHeaderValues sets an entry in the header of a Request to multiple values.
+//  NewRequest(query, WithHeaderValues("Accept-Language", "nl", "en"))
+func WithHeaderValues(key string, values ...string) RequestOption {
+	return func(r *Request) {
+		r.headers[http.CanonicalHeaderKey(key)] = values
+	}
+}
+
+// WithHeaderFunc adds a function that returns header entries for the Request, which replace the
+// entries of the headers of the Request and the Client with the same key.
+//  NewRequest(query, WithHeaderFunc(func(ctx context.Context, req *Request) http.Header {
+//      return http.Header{"X-Request-Id": {requestID(ctx)}}
+//  }))
+func WithHeaderFunc(fn HeaderFunc) RequestOption {
+	return func(r *Request) {
+		r.headerFuncs = append(r.headerFuncs, fn)
 	}
 }
 
@@ -50,3 +112,12 @@ func WithVar(name string, value interface{}) RequestOption {
 		r.Variables[name] = value
 	}
 }
+
+// WithDecodeOptions sets the DecodeOptions for the response of a Request, overriding the default
+// DecodeOptions of the Client.
+//  NewRequest(query, WithDecodeOptions(DecodeOptions{UseNumber: true}))
+func WithDecodeOptions(opts DecodeOptions) RequestOption {
+	return func(r *Request) {
+		r.decode = &opts
+	}
+}
@@ -2,7 +2,6 @@ package gqlclient
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"net/http"
 )
@@ -10,19 +9,28 @@ import (
 // JSONRequestBuilder creates an http.Request based on a GraphQL Request using a json encoding.
 func JSONRequestBuilder(endpoint string, req *Request) (*http.Request, error) {
 	// Encode the request as json
-	var requestBody bytes.Buffer
-	if err := json.NewEncoder(&requestBody).Encode(req); err != nil {
+	requestBody, err := req.getCodec().Marshal(req)
+	if err != nil {
 		return nil, fmt.Errorf("encode request body as json: %w", err)
 	}
 
+	// Compress the body if it exceeds the compression threshold
+	requestBody, contentEncoding, err := req.compressBody(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+
 	// Create a http POST request with the json body
-	r, err := http.NewRequest(http.MethodPost, endpoint, &requestBody)
+	r, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("create json request: %w", err)
 	}
 
 	// Set json content type
 	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if contentEncoding != "" {
+		r.Header.Set("Content-Encoding", contentEncoding)
+	}
 
 	return r, nil
 }
@@ -2,7 +2,6 @@ package gqlclient
 
 import (
 	"bytes"
-	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net/http"
@@ -13,19 +12,37 @@ func MultipartRequestBuilder(endpoint string, req *Request) (*http.Request, erro
 	// Encode the request as multipart request
 	var requestBody bytes.Buffer
 	writer := multipart.NewWriter(&requestBody)
-	if err := writer.WriteField("query", req.Query); err != nil {
-		return nil, fmt.Errorf("write query field: %w", err)
+	if req.Query != "" || req.DocumentID == "" {
+		if err := writer.WriteField("query", req.Query); err != nil {
+			return nil, fmt.Errorf("write query field: %w", err)
+		}
+	}
+	if req.DocumentID != "" {
+		if err := writer.WriteField("documentId", req.DocumentID); err != nil {
+			return nil, fmt.Errorf("write documentId field: %w", err)
+		}
 	}
 
 	// Encode and add the variables to the multipart request body.
 	if len(req.Variables) > 0 {
-		variablesField, err := writer.CreateFormField("variables")
+		variables, err := req.getCodec().Marshal(req.Variables)
 		if err != nil {
-			return nil, fmt.Errorf("create variables field: %w", err)
-		}
-		if err := json.NewEncoder(variablesField).Encode(req.Variables); err != nil {
 			return nil, fmt.Errorf("encode variables: %w", err)
 		}
+		if err := writer.WriteField("variables", string(variables)); err != nil {
+			return nil, fmt.Errorf("write variables field: %w", err)
+		}
+	}
+
+	// Encode and add the extensions to the multipart request body.
+	if len(req.Extensions) > 0 {
+		extensions, err := req.getCodec().Marshal(req.Extensions)
+		if err != nil {
+			return nil, fmt.Errorf("encode extensions: %w", err)
+		}
+		if err := writer.WriteField("extensions", string(extensions)); err != nil {
+			return nil, fmt.Errorf("write extensions field: %w", err)
+		}
 	}
 
 	// Close the multipart.Writer to finish the requestBody buffer.
@@ -33,14 +50,23 @@ func MultipartRequestBuilder(endpoint string, req *Request) (*http.Request, erro
 		return nil, fmt.Errorf("close writer: %w", err)
 	}
 
+	// Compress the body if it exceeds the compression threshold
+	body, contentEncoding, err := req.compressBody(requestBody.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+
 	// Create a http POST request with the multipart body
-	r, err := http.NewRequest(http.MethodPost, endpoint, &requestBody)
+	r, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create multipart request: %w", err)
 	}
 
 	// Set multipart content type
 	r.Header.Set("Content-Type", writer.FormDataContentType())
+	if contentEncoding != "" {
+		r.Header.Set("Content-Encoding", contentEncoding)
+	}
 
 	return r, nil
 }
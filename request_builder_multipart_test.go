@@ -45,6 +45,19 @@ func (s *SuiteMultipart) TestBody() {
 	s.Equal(`{"key":"value"}`+"\n", r.PostFormValue("variables"))
 }
 
+func (s *SuiteMultipart) TestDocumentID() {
+	req := gql.NewRequest("")
+	req.DocumentID = "abc"
+	req.Extensions = map[string]interface{}{"key": "value"}
+	r, err := gql.MultipartRequestBuilder("https://endpoint/query", req)
+	s.NoError(err)
+
+	s.Require().NoError(r.ParseMultipartForm(1024))
+	s.NotContains(r.MultipartForm.Value, "query")
+	s.Equal("abc", r.PostFormValue("documentId"))
+	s.Equal(`{"key":"value"}`+"\n", r.PostFormValue("extensions"))
+}
+
 func (s *SuiteMultipart) TestContentType() {
 	req := gql.NewRequest("query {}")
 	r, err := gql.MultipartRequestBuilder("https://endpoint/query", req)
@@ -2,6 +2,8 @@ package gqlclient
 
 import (
 	"bytes"
+	"encoding/json"
+	"net/http"
 	"strconv"
 
 	"github.com/vektah/gqlparser/v2/ast"
@@ -18,6 +20,22 @@ type response struct {
 	errorsResponse
 }
 
+// rawDataResponse contains the undecoded data and the errors entries of a GraphQL response.
+type rawDataResponse struct {
+	Data json.RawMessage `json:"data,omitempty"`
+	errorsResponse
+}
+
+// RawResponse contains the undecoded entries of a GraphQL response, together with the status code
+// and header of the http response.
+type RawResponse struct {
+	StatusCode int             `json:"-"`
+	Header     http.Header     `json:"-"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	Errors     json.RawMessage `json:"errors,omitempty"`
+	Extensions json.RawMessage `json:"extensions,omitempty"`
+}
+
 // errorsResponse contains only the errors entry of a GraphQL response.
 type errorsResponse struct {
 	Errors ErrorList `json:"errors,omitempty"`
@@ -0,0 +1,281 @@
+package gqlclient
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// CacheStore stores the cached responses of a response cache. Implementations must be safe for
+// concurrent use.
+type CacheStore interface {
+	// Get returns the value that is stored for the key, and false when there is no value or when it
+	// has expired.
+	Get(key string) ([]byte, bool)
+	// Set stores the value for the key, which expires after the ttl.
+	Set(key string, value []byte, ttl time.Duration)
+}
+
+// WithResponseCache caches the response bodies of queries in the store, by the endpoint, the
+// normalized query, the variables, the Authorization header and the values of the vary headers, for
+// the ttl. Mutations and responses with errors are not cached.
+//
+// The Authorization header is always part of the key, so that users do not get the responses of
+// other users. The token of WithTokenSource is not, as it is the same for every Request of the
+// Client; do not share the store between Clients with different token sources.
+//
+// A max-age in the Cache-Control header of a response overrides the ttl, and responses with
+// no-store or no-cache are not cached. A Request with a Cache-Control header of no-cache is sent
+// without looking in the cache, and with no-store its response is not cached either. Client.DoStream
+// uses cached responses, but does not cache the responses it streams.
+//  NewClient(endpoint, WithResponseCache(NewLRUCacheStore(1000), time.Minute, "Accept-Language"))
+func WithResponseCache(store CacheStore, ttl time.Duration, varyHeaders ...string) ClientOption {
+	return func(client *Client) {
+		client.responseCache = &responseCache{
+			store:       store,
+			ttl:         ttl,
+			varyHeaders: varyHeaders,
+		}
+	}
+}
+
+// responseCache caches response bodies in a CacheStore.
+type responseCache struct {
+	store       CacheStore
+	ttl         time.Duration
+	varyHeaders []string
+}
+
+// key returns the cache key for the request, or false when the request is not cacheable.
+func (rc *responseCache) key(endpoint string, q *cachedQuery, variables []byte, header requestHeader) (string, bool) {
+	if op := q.operation(); op == nil || op.Operation != ast.Query {
+		return "", false
+	}
+	normalized, err := q.normalize()
+	if err != nil {
+		return "", false
+	}
+	h := sha256.New()
+	for _, part := range []string{endpoint, normalized, string(variables)} {
+		fmt.Fprintf(h, "%d:%s", len(part), part)
+	}
+	for _, name := range append([]string{"Authorization"}, rc.varyHeaders...) {
+		value := strings.Join(header.values(name), ", ")
+		fmt.Fprintf(h, "%d:%s", len(value), value)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// get returns a response with the cached body for the key.
+func (rc *responseCache) get(key string, header requestHeader) (*http.Response, bool) {
+	if cacheControl(header.values("Cache-Control")).noCache {
+		return nil, false
+	}
+	body, ok := rc.store.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(body)),
+	}, true
+}
+
+// set stores the body of the response for the key, when the response is cacheable. The body of the
+// response is replaced, since it has been read.
+func (rc *responseCache) set(key string, header requestHeader, httpResp *http.Response, codec Codec) error {
+	reqControl := cacheControl(header.values("Cache-Control"))
+	respControl := cacheControl(httpResp.Header.Values("Cache-Control"))
+	ttl := rc.ttl
+	if respControl.maxAge != nil {
+		ttl = *respControl.maxAge
+	}
+	if httpResp.StatusCode != http.StatusOK || reqControl.noStore || respControl.noStore || respControl.noCache || ttl <= 0 {
+		return nil
+	}
+
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if cerr := httpResp.Body.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+	httpResp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var errs errorsResponse
+	if err := codec.NewDecoder(bytes.NewReader(body)).Decode(&errs); err != nil || len(errs.Errors) > 0 {
+		return nil
+	}
+	rc.store.Set(key, body, ttl)
+	return nil
+}
+
+// cacheDirectives are the directives of a Cache-Control header that the response cache uses.
+type cacheDirectives struct {
+	noCache bool
+	noStore bool
+	maxAge  *time.Duration
+}
+
+// cacheControl parses the values of a Cache-Control header.
+func cacheControl(values []string) cacheDirectives {
+	var d cacheDirectives
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg := strings.TrimSpace(strings.ToLower(directive)), ""
+			if i := strings.IndexByte(name, '='); i >= 0 {
+				name, arg = name[:i], strings.Trim(name[i+1:], `"`)
+			}
+			switch name {
+			case "no-cache":
+				d.noCache = true
+			case "no-store":
+				d.noStore = true
+			case "max-age":
+				if seconds, err := strconv.Atoi(arg); err == nil {
+					maxAge := time.Duration(seconds) * time.Second
+					d.maxAge = &maxAge
+				}
+			}
+		}
+	}
+	return d
+}
+
+// LRUCacheStore is an in-memory CacheStore that holds a maximum number of values, and removes the
+// least recently used value when it is full.
+type LRUCacheStore struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUCacheStore makes a new LRUCacheStore that holds at most capacity values.
+func NewLRUCacheStore(capacity int) *LRUCacheStore {
+	return &LRUCacheStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the value that is stored for the key, and false when there is no value or when it
+// has expired.
+func (s *LRUCacheStore) Get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+		return nil, false
+	}
+	s.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+// Set stores the value for the key, which expires after the ttl.
+func (s *LRUCacheStore) Set(key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &lruEntry{key: key, value: value, expires: time.Now().Add(ttl)}
+	if elem, ok := s.entries[key]; ok {
+		elem.Value = entry
+		s.order.MoveToFront(elem)
+		return
+	}
+	s.entries[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// DiskCacheStore is a CacheStore that stores values as files in a directory, so that they are kept
+// between runs of a program. Failures to read or write files are treated as cache misses.
+type DiskCacheStore struct {
+	dir string
+}
+
+// NewDiskCacheStore makes a new DiskCacheStore that stores values in the directory, which is
+// created when it does not exist.
+func NewDiskCacheStore(dir string) (*DiskCacheStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskCacheStore{dir: dir}, nil
+}
+
+// path returns the path of the file for the key.
+func (s *DiskCacheStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get returns the value that is stored for the key, and false when there is no value or when it
+// has expired.
+func (s *DiskCacheStore) Get(key string) ([]byte, bool) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if err != nil || len(data) < 8 {
+		return nil, false
+	}
+	expires := time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+	if time.Now().After(expires) {
+		_ = os.Remove(s.path(key))
+		return nil, false
+	}
+	return data[8:], true
+}
+
+// Set stores the value for the key, which expires after the ttl. The file is written to a temporary
+// file first, so that concurrent reads never see a partial value.
+func (s *DiskCacheStore) Set(key string, value []byte, ttl time.Duration) {
+	data := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(data, uint64(time.Now().Add(ttl).UnixNano()))
+	copy(data[8:], value)
+
+	tmp, err := ioutil.TempFile(s.dir, "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
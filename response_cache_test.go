@@ -0,0 +1,208 @@
+package gqlclient_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteResponseCache struct {
+	suite.Suite
+	httpClient *mocks.HTTPClient
+}
+
+func TestSuiteResponseCache(t *testing.T) {
+	s := SuiteResponseCache{}
+	suite.Run(t, &s)
+}
+
+// client returns a client with a response cache, which receives responses with the body and the
+// Cache-Control header.
+func (s *SuiteResponseCache) client(body, cacheControl string) *gql.Client {
+	s.httpClient = new(mocks.HTTPClient)
+	s.httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(func(*http.Request) *http.Response {
+			header := http.Header{}
+			if cacheControl != "" {
+				header.Set("Cache-Control", cacheControl)
+			}
+			return &http.Response{
+				Header:     header,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+				StatusCode: http.StatusOK,
+			}
+		}, nil)
+	return gql.NewClient("test", gql.WithHTTPClient(s.httpClient),
+		gql.WithResponseCache(gql.NewLRUCacheStore(10), time.Minute))
+}
+
+func (s *SuiteResponseCache) TestCachedQueries() {
+	c := s.client(`{"data": {"user": {"name": "Bob"}}}`, "")
+
+	for _, query := range []string{
+		`query ($id: ID!) { user(id: $id) { name } }`,
+		// The same normalized query.
+		"# Get a user.\nquery ($id: ID!) {\n\tuser(id: $id) {\n\t\tname\n\t}\n}",
+	} {
+		var resp struct {
+			User struct {
+				Name string
+			}
+		}
+		s.Require().NoError(c.Do(gql.NewRequest(query, gql.WithVar("id", "1")), &resp))
+		s.Equal("Bob", resp.User.Name)
+	}
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 1)
+
+	// Other variables and Authorization headers have their own cache entries.
+	query := `query ($id: ID!) { user(id: $id) { name } }`
+	s.Require().NoError(c.Do(gql.NewRequest(query, gql.WithVar("id", "2")), nil))
+	s.Require().NoError(c.Do(gql.NewRequest(query, gql.WithVar("id", "1"), gql.WithHeader("Authorization", "a")), nil))
+	s.Require().NoError(c.Do(gql.NewRequest(query, gql.WithVar("id", "1"), gql.WithHeader("Authorization", "a")), nil))
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 3)
+
+	// No-cache requests are sent, and update the cache.
+	s.Require().NoError(c.Do(gql.NewRequest(query, gql.WithVar("id", "2"), gql.WithHeader("Cache-Control", "no-cache")), nil))
+	s.Require().NoError(c.Do(gql.NewRequest(query, gql.WithVar("id", "2")), nil))
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 4)
+
+	raw, err := c.DoRaw(gql.NewRequest(query, gql.WithVar("id", "2")))
+	s.Require().NoError(err)
+	s.JSONEq(`{"user": {"name": "Bob"}}`, string(raw.Data))
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 4)
+}
+
+// countingSource returns a token, and counts its calls.
+type countingSource struct {
+	calls int
+}
+
+func (c *countingSource) Token(context.Context) (*gql.Token, error) {
+	c.calls++
+	return &gql.Token{AccessToken: "t1", Expiry: time.Now().Add(-time.Second)}, nil
+}
+
+func (s *SuiteResponseCache) TestCacheHitWithoutToken() {
+	s.client(`{"data": {"user": {"name": "Bob"}}}`, "")
+	source := &countingSource{}
+	var signed int
+	c := gql.NewClient("test", gql.WithHTTPClient(s.httpClient),
+		gql.WithResponseCache(gql.NewLRUCacheStore(10), time.Minute),
+		gql.WithTokenSource(source, time.Minute),
+		gql.WithSigner(gql.SignerFunc(func(*http.Request, []byte) error {
+			signed++
+			return nil
+		})))
+
+	// The expired token is fetched again for every request that is sent, but not for cache hits.
+	for i := 0; i < 3; i++ {
+		s.Require().NoError(c.Do(gql.NewRequest(`{ user(id: 1) { name } }`), nil))
+	}
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 1)
+	s.Equal(1, source.calls)
+	s.Equal(1, signed)
+}
+
+func (s *SuiteResponseCache) TestStream() {
+	c := s.client(`{"data": {"users": [{"name": "Bob"}]}}`, "")
+	query := `{ users { name } }`
+	stream := func() {
+		var names []string
+		s.Require().NoError(c.DoStream(gql.NewRequest(query), "data.users", func(_ int, elem gql.Decoder) error {
+			var user struct{ Name string }
+			err := elem.Decode(&user)
+			names = append(names, user.Name)
+			return err
+		}))
+		s.Equal([]string{"Bob"}, names)
+	}
+
+	// Streamed responses are not cached.
+	stream()
+	stream()
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 2)
+
+	// Cached responses are streamed.
+	s.Require().NoError(c.Do(gql.NewRequest(query), nil))
+	stream()
+	s.httpClient.AssertNumberOfCalls(s.T(), "Do", 3)
+}
+
+func (s *SuiteResponseCache) TestNotCached() {
+	tests := []struct {
+		body, cacheControl, query string
+		opts                      []gql.RequestOption
+	}{
+		{body: `{"data": {"rename": {"name": "Bob"}}}`, query: `mutation { rename(id: 1, name: "Bob") { name } }`},
+		{body: `{"errors": [{"message": "error"}]}`, query: `{ user(id: 1) { name } }`},
+		{body: `{"data": {"user": null}}`, cacheControl: "no-store", query: `{ user(id: 1) { name } }`},
+		{body: `{"data": {"user": null}}`, cacheControl: "private, max-age=0", query: `{ user(id: 1) { name } }`},
+		{body: `{"data": {"user": null}}`, query: `{ user(id: 1) { name } }`,
+			opts: []gql.RequestOption{gql.WithHeader("Cache-Control", "no-store")}},
+		{body: `{"data": {"user": null}}`, query: `{ user(id: 1) { name } } { user(id: 2) { name } }`},
+	}
+	for _, test := range tests {
+		c := s.client(test.body, test.cacheControl)
+		for i := 0; i < 2; i++ {
+			_ = c.Do(gql.NewRequest(test.query, test.opts...), nil)
+		}
+		s.httpClient.AssertNumberOfCalls(s.T(), "Do", 2)
+	}
+}
+
+func (s *SuiteResponseCache) TestLRUCacheStore() {
+	store := gql.NewLRUCacheStore(2)
+	store.Set("a", []byte("1"), time.Minute)
+	store.Set("b", []byte("2"), time.Minute)
+	_, ok := store.Get("a")
+	s.True(ok)
+
+	// The least recently used value is removed.
+	store.Set("c", []byte("3"), time.Minute)
+	_, ok = store.Get("b")
+	s.False(ok)
+	value, ok := store.Get("a")
+	s.True(ok)
+	s.Equal([]byte("1"), value)
+
+	store.Set("a", []byte("4"), -time.Second)
+	_, ok = store.Get("a")
+	s.False(ok)
+}
+
+func (s *SuiteResponseCache) TestDiskCacheStore() {
+	dir, err := ioutil.TempDir("", "gqlclient")
+	s.Require().NoError(err)
+	defer os.RemoveAll(dir)
+
+	store, err := gql.NewDiskCacheStore(dir)
+	s.Require().NoError(err)
+	store.Set("a", []byte("1"), time.Minute)
+	store.Set("b", []byte("2"), -time.Second)
+
+	// Values are kept between stores in the same directory.
+	store, err = gql.NewDiskCacheStore(dir)
+	s.Require().NoError(err)
+	value, ok := store.Get("a")
+	s.True(ok)
+	s.Equal([]byte("1"), value)
+	_, ok = store.Get("b")
+	s.False(ok)
+	_, ok = store.Get("c")
+	s.False(ok)
+
+	files, err := ioutil.ReadDir(dir)
+	s.Require().NoError(err)
+	s.Len(files, 1)
+}
@@ -0,0 +1,279 @@
+package gqlclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+)
+
+// FormatSchemaSDL renders the schema as SDL text, without the types and directives that are built
+// into GraphQL.
+func FormatSchemaSDL(schema *ast.Schema) string {
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatSchema(schema)
+	return buf.String()
+}
+
+// FormatSchemaJSON renders the schema as the json result of the standard introspection query.
+func FormatSchemaJSON(schema *ast.Schema) ([]byte, error) {
+	return json.MarshalIndent(IntrospectionResponse{Schema: NewIntrospectionSchema(schema)}, "", "  ")
+}
+
+// LoadSchemaSDL loads a schema from SDL files. A path to a directory loads all the .graphql and
+// .graphqls files in that directory.
+func LoadSchemaSDL(paths ...string) (*ast.Schema, error) {
+	var sources []*ast.Source
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files := []string{path}
+		if info.IsDir() {
+			if files, err = schemaFiles(path); err != nil {
+				return nil, err
+			}
+		}
+		for _, file := range files {
+			input, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, &ast.Source{Name: file, Input: string(input)})
+		}
+	}
+
+	schema, gerr := gqlparser.LoadSchema(sources...)
+	if gerr != nil {
+		return nil, gerr
+	}
+	return schema, nil
+}
+
+// schemaFiles returns the sorted paths of the SDL files in a directory.
+func schemaFiles(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".graphql" || ext == ".graphqls") {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// LoadSchemaJSON loads a schema from a file with the json result of an introspection query. Both
+// the data of the result and the complete GraphQL response are accepted.
+func LoadSchemaJSON(path string) (*ast.Schema, error) {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Data *IntrospectionResponse `json:"data"`
+		IntrospectionResponse
+	}
+	if err := json.Unmarshal(input, &resp); err != nil {
+		return nil, fmt.Errorf("decode introspection: %w", err)
+	}
+	introspection := resp.Schema
+	if resp.Data != nil {
+		introspection = resp.Data.Schema
+	}
+	if introspection == nil {
+		return nil, fmt.Errorf("decode introspection: no __schema in %s", path)
+	}
+	return introspection.BuildSchema()
+}
+
+// NewIntrospectionSchema returns the introspection result for the schema, like a server would
+// return it for the standard introspection query.
+func NewIntrospectionSchema(schema *ast.Schema) *IntrospectionSchema {
+	is := &IntrospectionSchema{
+		QueryType:        introspectionTypeName(schema.Query),
+		MutationType:     introspectionTypeName(schema.Mutation),
+		SubscriptionType: introspectionTypeName(schema.Subscription),
+	}
+
+	typeNames := make([]string, 0, len(schema.Types))
+	for name := range schema.Types {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+	for _, name := range typeNames {
+		is.Types = append(is.Types, introspectionType(schema, schema.Types[name]))
+	}
+
+	directiveNames := make([]string, 0, len(schema.Directives))
+	for name := range schema.Directives {
+		directiveNames = append(directiveNames, name)
+	}
+	sort.Strings(directiveNames)
+	for _, name := range directiveNames {
+		def := schema.Directives[name]
+		dir := &IntrospectionDirective{
+			Name:        def.Name,
+			Description: optionalString(def.Description),
+			Locations:   []string{},
+			Args:        introspectionArguments(schema, def.Arguments),
+		}
+		for _, location := range def.Locations {
+			dir.Locations = append(dir.Locations, string(location))
+		}
+		is.Directives = append(is.Directives, dir)
+	}
+	return is
+}
+
+func introspectionTypeName(def *ast.Definition) *IntrospectionTypeName {
+	if def == nil {
+		return nil
+	}
+	return &IntrospectionTypeName{Name: def.Name}
+}
+
+func introspectionType(schema *ast.Schema, def *ast.Definition) *IntrospectionType {
+	t := &IntrospectionType{
+		Kind:        string(def.Kind),
+		Name:        def.Name,
+		Description: optionalString(def.Description),
+	}
+	if dir := def.Directives.ForName("specifiedBy"); dir != nil {
+		if url := dir.Arguments.ForName("url"); url != nil && url.Value != nil {
+			t.SpecifiedByURL = &url.Value.Raw
+		}
+	}
+
+	switch def.Kind {
+	case ast.Object, ast.Interface:
+		t.Fields = []*IntrospectionField{}
+		for _, f := range def.Fields {
+			if strings.HasPrefix(f.Name, "__") {
+				// Skip the introspection fields that are added to the query type.
+				continue
+			}
+			isDeprecated, reason := deprecation(f.Directives)
+			t.Fields = append(t.Fields, &IntrospectionField{
+				Name:              f.Name,
+				Description:       optionalString(f.Description),
+				Args:              introspectionArguments(schema, f.Arguments),
+				Type:              introspectionTypeRef(schema, f.Type),
+				IsDeprecated:      isDeprecated,
+				DeprecationReason: reason,
+			})
+		}
+		t.Interfaces = []*IntrospectionTypeRef{}
+		for _, name := range def.Interfaces {
+			t.Interfaces = append(t.Interfaces, introspectionTypeRef(schema, ast.NamedType(name, nil)))
+		}
+	case ast.InputObject:
+		t.InputFields = []*IntrospectionInputValue{}
+		for _, f := range def.Fields {
+			t.InputFields = append(t.InputFields, introspectionInputValue(schema, &ast.ArgumentDefinition{
+				Description:  f.Description,
+				Name:         f.Name,
+				DefaultValue: f.DefaultValue,
+				Type:         f.Type,
+				Directives:   f.Directives,
+			}))
+		}
+	case ast.Enum:
+		t.EnumValues = []*IntrospectionEnumValue{}
+		for _, v := range def.EnumValues {
+			isDeprecated, reason := deprecation(v.Directives)
+			t.EnumValues = append(t.EnumValues, &IntrospectionEnumValue{
+				Name:              v.Name,
+				Description:       optionalString(v.Description),
+				IsDeprecated:      isDeprecated,
+				DeprecationReason: reason,
+			})
+		}
+	}
+
+	if def.IsAbstractType() {
+		t.PossibleTypes = []*IntrospectionTypeRef{}
+		possibleTypes := append([]*ast.Definition{}, schema.GetPossibleTypes(def)...)
+		sort.Slice(possibleTypes, func(i, j int) bool {
+			return possibleTypes[i].Name < possibleTypes[j].Name
+		})
+		for _, p := range possibleTypes {
+			t.PossibleTypes = append(t.PossibleTypes, introspectionTypeRef(schema, ast.NamedType(p.Name, nil)))
+		}
+	}
+	return t
+}
+
+func introspectionArguments(schema *ast.Schema, args ast.ArgumentDefinitionList) []*IntrospectionInputValue {
+	values := []*IntrospectionInputValue{}
+	for _, arg := range args {
+		values = append(values, introspectionInputValue(schema, arg))
+	}
+	return values
+}
+
+func introspectionInputValue(schema *ast.Schema, arg *ast.ArgumentDefinition) *IntrospectionInputValue {
+	v := &IntrospectionInputValue{
+		Name:        arg.Name,
+		Description: optionalString(arg.Description),
+		Type:        introspectionTypeRef(schema, arg.Type),
+	}
+	if arg.DefaultValue != nil {
+		defaultValue := arg.DefaultValue.String()
+		v.DefaultValue = &defaultValue
+	}
+	v.IsDeprecated, v.DeprecationReason = deprecation(arg.Directives)
+	return v
+}
+
+func introspectionTypeRef(schema *ast.Schema, typ *ast.Type) *IntrospectionTypeRef {
+	if typ.NonNull {
+		nullable := *typ
+		nullable.NonNull = false
+		return &IntrospectionTypeRef{Kind: "NON_NULL", OfType: introspectionTypeRef(schema, &nullable)}
+	}
+	if typ.Elem != nil {
+		return &IntrospectionTypeRef{Kind: "LIST", OfType: introspectionTypeRef(schema, typ.Elem)}
+	}
+	ref := &IntrospectionTypeRef{Name: &typ.NamedType}
+	if def := schema.Types[typ.NamedType]; def != nil {
+		ref.Kind = string(def.Kind)
+	}
+	return ref
+}
+
+// deprecation returns whether the @deprecated directive is in the list and its reason.
+func deprecation(directives ast.DirectiveList) (bool, *string) {
+	dir := directives.ForName("deprecated")
+	if dir == nil {
+		return false, nil
+	}
+	if reason := dir.Arguments.ForName("reason"); reason != nil && reason.Value != nil {
+		return true, &reason.Value.Raw
+	}
+	if dir.Definition != nil {
+		if reason := dir.Definition.Arguments.ForName("reason"); reason != nil && reason.DefaultValue != nil {
+			return true, &reason.DefaultValue.Raw
+		}
+	}
+	return true, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
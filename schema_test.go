@@ -0,0 +1,130 @@
+package gqlclient_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+)
+
+type SuiteSchema struct {
+	suite.Suite
+	dirs []string
+}
+
+func TestSuiteSchema(t *testing.T) {
+	s := SuiteSchema{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteSchema) tempDir() string {
+	dir, err := ioutil.TempDir("", "gqlclient")
+	s.Require().NoError(err)
+	s.dirs = append(s.dirs, dir)
+	return dir
+}
+
+func (s *SuiteSchema) TearDownTest() {
+	for _, dir := range s.dirs {
+		_ = os.RemoveAll(dir)
+	}
+	s.dirs = nil
+}
+
+func (s *SuiteSchema) writeFile(dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	s.Require().NoError(ioutil.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func (s *SuiteSchema) TestLoadSchemaJSON() {
+	schema, err := gql.LoadSchemaJSON("testdata/introspection.json")
+	s.Require().NoError(err)
+	s.Equal("Root", schema.Query.Name)
+	s.NotNil(schema.Types["User"])
+}
+
+func (s *SuiteSchema) TestLoadSchemaJSONWithoutSchema() {
+	dir := s.tempDir()
+	path := s.writeFile(dir, "schema.json", `{"data": {}}`)
+
+	_, err := gql.LoadSchemaJSON(path)
+	s.Error(err)
+}
+
+func (s *SuiteSchema) TestLoadSchemaSDL() {
+	dir := s.tempDir()
+	s.writeFile(dir, "query.graphql", `type Query { user(id: ID!): User }`)
+	s.writeFile(dir, "user.graphqls", `type User { id: ID! name: String }`)
+	s.writeFile(dir, "README.md", `not a schema`)
+
+	schema, err := gql.LoadSchemaSDL(dir)
+	s.Require().NoError(err)
+	s.Equal("Query", schema.Query.Name)
+	s.NotNil(schema.Types["User"])
+}
+
+func (s *SuiteSchema) TestLoadSchemaSDLInvalid() {
+	dir := s.tempDir()
+	path := s.writeFile(dir, "schema.graphql", `type Query { user: User }`)
+
+	_, err := gql.LoadSchemaSDL(path)
+	s.Error(err)
+}
+
+func (s *SuiteSchema) TestSDLRoundTrip() {
+	schema, err := gql.LoadSchemaJSON("testdata/introspection.json")
+	s.Require().NoError(err)
+	sdl := gql.FormatSchemaSDL(schema)
+	s.Contains(sdl, "schema {")
+	s.Contains(sdl, `name(format: String = "full"): String @deprecated(reason: "Use fullName.")`)
+	s.Contains(sdl, `scalar DateTime @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")`)
+	s.NotContains(sdl, "__schema")
+
+	path := s.writeFile(s.tempDir(), "schema.graphql", sdl)
+	loaded, err := gql.LoadSchemaSDL(path)
+	s.Require().NoError(err)
+	s.Equal(sdl, gql.FormatSchemaSDL(loaded))
+}
+
+func (s *SuiteSchema) TestJSONRoundTrip() {
+	schema, err := gql.LoadSchemaJSON("testdata/introspection.json")
+	s.Require().NoError(err)
+	data, err := gql.FormatSchemaJSON(schema)
+	s.Require().NoError(err)
+
+	path := s.writeFile(s.tempDir(), "schema.json", string(data))
+	loaded, err := gql.LoadSchemaJSON(path)
+	s.Require().NoError(err)
+	s.Equal(gql.FormatSchemaSDL(schema), gql.FormatSchemaSDL(loaded))
+
+	again, err := gql.FormatSchemaJSON(loaded)
+	s.Require().NoError(err)
+	s.JSONEq(string(data), string(again))
+}
+
+func (s *SuiteSchema) TestNewIntrospectionSchema() {
+	schema, err := gql.LoadSchemaJSON("testdata/introspection.json")
+	s.Require().NoError(err)
+
+	is := gql.NewIntrospectionSchema(schema)
+	s.Equal("Root", is.QueryType.Name)
+	s.Nil(is.MutationType)
+	for _, t := range is.Types {
+		if t.Name != "User" {
+			continue
+		}
+		s.Equal("OBJECT", t.Kind)
+		s.Len(t.Interfaces, 1)
+		for _, f := range t.Fields {
+			if f.Name == "name" {
+				s.True(f.IsDeprecated)
+				s.Equal("Use fullName.", *f.DeprecationReason)
+			}
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package gqlclient
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// ShapeMismatch describes a selected field that the response object cannot hold.
+type ShapeMismatch struct {
+	// Path of the field in the response data.
+	Path    string
+	Message string
+}
+
+func (m ShapeMismatch) String() string {
+	return fmt.Sprintf("%s: %s", m.Path, m.Message)
+}
+
+// ShapeError is used when the response object cannot hold every field that is selected by the
+// query. It contains all the mismatches that were found.
+type ShapeError struct {
+	Mismatches []ShapeMismatch
+}
+
+func (e *ShapeError) Error() string {
+	messages := make([]string, 0, len(e.Mismatches))
+	for _, m := range e.Mismatches {
+		messages = append(messages, m.String())
+	}
+	return fmt.Sprintf("response object does not match selection set: %s", strings.Join(messages, "; "))
+}
+
+// errShapeCheckSchema is used when the shape check is enabled without a schema.
+var errShapeCheckSchema = errors.New("shape check requires a schema, use WithSchema")
+
+// CheckShape checks that the response object v can hold every field that is selected by the query,
+// using the json names of the struct fields, list and object types, and pointers for nullable
+// fields. The selected fields of an object can also be decoded into the elements of a map with
+// string keys. Use it to check response objects when the client is constructed, or in tests. It returns
+// a *ShapeError when there are mismatches.
+//  err := CheckShape(schema, query, &resp)
+func CheckShape(schema *ast.Schema, query string, v interface{}) error {
+	doc, errs := gqlparser.LoadQuery(schema, query)
+	if len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return checkShape(doc, reflect.TypeOf(v))
+}
+
+// checkShape checks the type against the selection set of the only operation in the document.
+func checkShape(doc *ast.QueryDocument, t reflect.Type) error {
+	if len(doc.Operations) != 1 || t == nil {
+		return nil
+	}
+	var mismatches []ShapeMismatch
+	shapeSelectionSet(&mismatches, "", doc.Operations[0].SelectionSet, t)
+	if len(mismatches) > 0 {
+		return &ShapeError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+// shapeSelectionSet checks that the type is an object that can hold the selections.
+func shapeSelectionSet(mismatches *[]ShapeMismatch, path string, selections ast.SelectionSet, t reflect.Type) {
+	t = derefType(t)
+	if !checkableType(t) {
+		return
+	}
+	// The selected fields of a map are decoded into its elements.
+	isMap := t.Kind() == reflect.Map && mapKeyOK(t.Key())
+	if t.Kind() != reflect.Struct && !isMap {
+		*mismatches = append(*mismatches, ShapeMismatch{Path: path, Message: fmt.Sprintf("object cannot be decoded into %s", t)})
+		return
+	}
+	var fields []jsonField
+	if !isMap {
+		fields = jsonFields(t)
+	}
+
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			fieldPath := joinPath(path, selection.Alias)
+			fieldType := t
+			if isMap {
+				fieldType = t.Elem()
+			} else {
+				f, ok := findField(fields, selection.Alias)
+				if !ok {
+					*mismatches = append(*mismatches, ShapeMismatch{Path: fieldPath, Message: fmt.Sprintf("no field in %s", t)})
+					continue
+				}
+				fieldType = f.typ
+			}
+			if selection.Definition != nil {
+				shapeField(mismatches, fieldPath, selection, selection.Definition.Type, fieldType)
+			}
+		case *ast.InlineFragment:
+			shapeSelectionSet(mismatches, path, selection.SelectionSet, t)
+		case *ast.FragmentSpread:
+			if selection.Definition != nil {
+				shapeSelectionSet(mismatches, path, selection.Definition.SelectionSet, t)
+			}
+		}
+	}
+}
+
+// shapeField checks that the type of a struct field can hold the value of the selected field.
+func shapeField(mismatches *[]ShapeMismatch, path string, field *ast.Field, typ *ast.Type, t reflect.Type) {
+	if !typ.NonNull && !nillable(t) {
+		*mismatches = append(*mismatches, ShapeMismatch{Path: path, Message: fmt.Sprintf("nullable %s cannot be decoded into %s, use a pointer", typ, t)})
+		return
+	}
+	t = derefType(t)
+	if !checkableType(t) {
+		return
+	}
+
+	if typ.Elem != nil {
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			*mismatches = append(*mismatches, ShapeMismatch{Path: path, Message: fmt.Sprintf("list %s cannot be decoded into %s", typ, t)})
+			return
+		}
+		shapeField(mismatches, path, field, typ.Elem, t.Elem())
+		return
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 || t.Kind() == reflect.Array {
+		*mismatches = append(*mismatches, ShapeMismatch{Path: path, Message: fmt.Sprintf("%s is not a list and cannot be decoded into %s", typ, t)})
+		return
+	}
+
+	if len(field.SelectionSet) > 0 {
+		shapeSelectionSet(mismatches, path, field.SelectionSet, t)
+		return
+	}
+	if !scalarKindOK(typ.NamedType, t.Kind()) {
+		*mismatches = append(*mismatches, ShapeMismatch{Path: path, Message: fmt.Sprintf("%s cannot be decoded into %s", typ, t)})
+	}
+}
+
+// scalarKindOK reports whether a value of a leaf type can be decoded into a value of the kind.
+// Custom scalars and enums are only checked to not be decoded into a struct or map.
+func scalarKindOK(typeName string, kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Interface:
+		return true
+	case reflect.Struct, reflect.Map:
+		return false
+	}
+	switch typeName {
+	case "Int":
+		return kind >= reflect.Int && kind <= reflect.Float64
+	case "Float":
+		return kind == reflect.Float32 || kind == reflect.Float64
+	case "String":
+		return kind == reflect.String
+	case "Boolean":
+		return kind == reflect.Bool
+	case "ID":
+		return kind == reflect.String || kind >= reflect.Int && kind <= reflect.Uint64
+	}
+	return true
+}
+
+// findField finds the struct field for a json key, preferring an exact match but falling back to a
+// case-insensitive match like encoding/json does.
+func findField(fields []jsonField, key string) (jsonField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// derefType returns the type that pointer types point to.
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr && !t.Implements(unmarshalerType) {
+		t = t.Elem()
+	}
+	return t
+}
+
+// checkableType reports whether the shape of the type can be checked. Types that decode themselves
+// and interfaces can hold anything.
+func checkableType(t reflect.Type) bool {
+	return t.Kind() != reflect.Interface && !t.Implements(unmarshalerType) &&
+		!reflect.PtrTo(t).Implements(unmarshalerType)
+}
+
+// mapKeyOK reports whether encoding/json can decode object keys into the map key type, which must
+// be a string, an integer or implement encoding.TextUnmarshaler.
+func mapKeyOK(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// nillable reports whether a json null can be decoded into the type without losing the null.
+func nillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// shapeChecker checks the shape of response objects, and caches the results per query and type in
+// the query cache.
+type shapeChecker struct{}
+
+// check checks the shape of the response object against the validated document of the query.
+func (c *shapeChecker) check(q *cachedQuery, doc *ast.QueryDocument, v interface{}) error {
+	t := reflect.TypeOf(v)
+	q.mu.RLock()
+	err, ok := q.shapes[t]
+	q.mu.RUnlock()
+	if ok {
+		return err
+	}
+
+	err = checkShape(doc, t)
+	q.mu.Lock()
+	if q.shapes == nil {
+		q.shapes = make(map[reflect.Type]error)
+	}
+	q.shapes[t] = err
+	q.mu.Unlock()
+	return err
+}
+
+// WithShapeCheck enables a debug mode that checks that the response object of every Request can
+// hold every field that is selected by the query, before the Request is sent. It requires a schema,
+// which is set using WithSchema. A *ShapeError is returned when the response object does not match.
+//  NewClient(endpoint, WithSchema(schema), WithShapeCheck())
+func WithShapeCheck() ClientOption {
+	return func(client *Client) {
+		client.shapes = &shapeChecker{}
+	}
+}
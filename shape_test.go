@@ -0,0 +1,216 @@
+package gqlclient_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+var shapeSchema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Query {
+		user(id: ID!): User
+		users: [User!]!
+	}
+	type User {
+		id: ID!
+		name: String!
+		age: Int
+		score: Float!
+		friends: [User!]
+	}
+`})
+
+const shapeQuery = `query ($id: ID!) {
+	user(id: $id) {
+		id
+		fullName: name
+		age
+		...Friends
+	}
+}
+
+fragment Friends on User {
+	friends {
+		... on User {
+			name
+		}
+	}
+}`
+
+type SuiteShape struct {
+	suite.Suite
+}
+
+func TestSuiteShape(t *testing.T) {
+	s := SuiteShape{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteShape) TestValid() {
+	type friend struct {
+		Name string
+	}
+	var resp struct {
+		User *struct {
+			ID       int64 `json:"id"`
+			FullName string
+			Age      *int
+			Friends  []friend
+		}
+	}
+	s.NoError(gql.CheckShape(shapeSchema, shapeQuery, &resp))
+}
+
+func (s *SuiteShape) TestMismatches() {
+	var resp struct {
+		User struct {
+			ID      string
+			Name    string
+			Age     *string
+			Friends struct {
+				Name string
+			}
+		}
+	}
+	err := gql.CheckShape(shapeSchema, shapeQuery, &resp)
+
+	var serr *gql.ShapeError
+	s.Require().ErrorAs(err, &serr)
+	var messages []string
+	for _, m := range serr.Mismatches {
+		messages = append(messages, m.String())
+	}
+	s.Equal([]string{
+		"user: nullable User cannot be decoded into struct { ID string; Name string; Age *string; " +
+			"Friends struct { Name string } }, use a pointer",
+	}, messages)
+
+	var respPtr struct {
+		User *struct {
+			ID      string
+			Name    string
+			Age     *string
+			Friends *struct {
+				Name string
+			}
+		}
+	}
+	err = gql.CheckShape(shapeSchema, shapeQuery, &respPtr)
+	s.Require().ErrorAs(err, &serr)
+	messages = nil
+	for _, m := range serr.Mismatches {
+		messages = append(messages, m.String())
+	}
+	s.Equal([]string{
+		"user.fullName: no field in struct { ID string; Name string; Age *string; Friends *struct { Name string } }",
+		"user.age: Int cannot be decoded into string",
+		"user.friends: list [User!] cannot be decoded into struct { Name string }",
+	}, messages)
+}
+
+func (s *SuiteShape) TestUnmarshalerAndInterface() {
+	var resp struct {
+		User *struct {
+			ID       json.RawMessage
+			FullName interface{}
+			Age      json.Number
+			Friends  json.RawMessage
+		}
+	}
+	err := gql.CheckShape(shapeSchema, shapeQuery, &resp)
+
+	// Only the nullability of age is checked, the rest can hold any value.
+	var serr *gql.ShapeError
+	s.Require().ErrorAs(err, &serr)
+	s.Require().Len(serr.Mismatches, 1)
+	s.Equal("user.age", serr.Mismatches[0].Path)
+}
+
+// fieldKey is a map key that is decoded by its UnmarshalText method.
+type fieldKey struct {
+	name string
+}
+
+func (k *fieldKey) UnmarshalText(text []byte) error {
+	k.name = string(text)
+	return nil
+}
+
+func (s *SuiteShape) TestMaps() {
+	// The selected fields are decoded into the elements of a map.
+	s.NoError(gql.CheckShape(shapeSchema, `{ user(id: 1) { name } }`, &map[string]interface{}{}))
+
+	var resp struct {
+		User  map[string]string
+		Users []map[string]interface{}
+	}
+	s.NoError(gql.CheckShape(shapeSchema, `{ user(id: 1) { id name } users { name friends { id } } }`, &resp))
+
+	err := gql.CheckShape(shapeSchema, `{ user(id: 1) { name friends { id } } }`, &resp)
+	var serr *gql.ShapeError
+	s.Require().ErrorAs(err, &serr)
+	s.Require().Len(serr.Mismatches, 1)
+	s.Equal("user.friends: nullable [User!] cannot be decoded into string, use a pointer", serr.Mismatches[0].String())
+
+	// The keys can be decoded into the key types that encoding/json supports.
+	var keys struct {
+		User  map[int]string
+		Users []map[fieldKey]interface{}
+	}
+	s.NoError(gql.CheckShape(shapeSchema, `{ user(id: 1) { name } users { name } }`, &keys))
+
+	var boolKeys struct {
+		User map[bool]string
+	}
+	err = gql.CheckShape(shapeSchema, `{ user(id: 1) { name } }`, &boolKeys)
+	s.Require().ErrorAs(err, &serr)
+	s.Equal("user: object cannot be decoded into map[bool]string", serr.Mismatches[0].String())
+}
+
+func (s *SuiteShape) TestWithShapeCheck() {
+	httpClient := new(mocks.HTTPClient)
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient),
+		gql.WithSchema(shapeSchema), gql.WithShapeCheck())
+
+	var resp struct {
+		Users []struct {
+			Name int
+		}
+	}
+	err := c.Do(gql.NewRequest(`{ users { name } }`), &resp)
+	var serr *gql.ShapeError
+	s.ErrorAs(err, &serr)
+	httpClient.AssertNotCalled(s.T(), "Do", mock.Anything)
+
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data": {"users": [{"score": 1.5}]}}`)),
+			StatusCode: http.StatusOK,
+		}, nil)
+	var valid struct {
+		Users []struct {
+			Score float64
+		}
+	}
+	err = c.Do(gql.NewRequest(`{ users { score } }`), &valid)
+	s.NoError(err)
+	s.Equal(1.5, valid.Users[0].Score)
+}
+
+func (s *SuiteShape) TestWithShapeCheckWithoutSchema() {
+	var resp struct{}
+	c := gql.NewClient("test", gql.WithShapeCheck())
+	err := c.Do(gql.NewRequest(`{ users { name } }`), &resp)
+	s.Error(err)
+}
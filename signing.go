@@ -0,0 +1,230 @@
+package gqlclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signer signs the http request of a Request after its body and headers are complete, for example
+// by setting an Authorization or signature header. The body is the final, possibly compressed, body
+// of the http request.
+type Signer interface {
+	Sign(httpReq *http.Request, body []byte) error
+}
+
+// SignerFunc is a function that implements Signer.
+type SignerFunc func(httpReq *http.Request, body []byte) error
+
+// Sign calls f.
+func (f SignerFunc) Sign(httpReq *http.Request, body []byte) error {
+	return f(httpReq, body)
+}
+
+// WithSigner signs the http request of every Request with the Signer, after all headers are set.
+//  NewClient(endpoint, WithSigner(&SigV4Signer{
+//      AccessKeyID:     id,
+//      SecretAccessKey: secret,
+//      Region:          "eu-west-1",
+//      Service:         "appsync",
+//  }))
+func WithSigner(signer Signer) ClientOption {
+	return func(client *Client) {
+		client.signer = signer
+	}
+}
+
+// sign reads the body of the http request, replaces it, and signs the http request.
+func sign(signer Signer, httpReq *http.Request) error {
+	var body []byte
+	if httpReq.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(httpReq.Body); err != nil {
+			return fmt.Errorf("read request body: %w", err)
+		}
+		if err := httpReq.Body.Close(); err != nil {
+			return fmt.Errorf("close request body: %w", err)
+		}
+		httpReq.Body = ioutil.NopCloser(bytes.NewReader(body))
+		httpReq.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	if err := signer.Sign(httpReq, body); err != nil {
+		return fmt.Errorf("sign request: %w", err)
+	}
+	return nil
+}
+
+// HMACSigner signs requests with the hex encoded HMAC-SHA256 of the timestamp, method, path and
+// body of the request, separated by newlines. The timestamp is the unix time in seconds, and is
+// sent in the timestamp header.
+//  X-Timestamp: 1600000000
+//  X-Signature: hex(hmac(key, "1600000000\nPOST\n/graphql\n{\"query\":...}"))
+type HMACSigner struct {
+	Key []byte
+	// Header is the name of the signature header, which defaults to X-Signature.
+	Header string
+	// TimestampHeader is the name of the timestamp header, which defaults to X-Timestamp.
+	TimestampHeader string
+
+	now func() time.Time
+}
+
+// Sign sets the timestamp and signature headers of the http request.
+func (s *HMACSigner) Sign(httpReq *http.Request, body []byte) error {
+	header, timestampHeader := s.Header, s.TimestampHeader
+	if header == "" {
+		header = "X-Signature"
+	}
+	if timestampHeader == "" {
+		timestampHeader = "X-Timestamp"
+	}
+	timestamp := strconv.FormatInt(signingTime(s.now).Unix(), 10)
+
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(timestamp + "\n" + httpReq.Method + "\n" + httpReq.URL.EscapedPath() + "\n"))
+	mac.Write(body)
+	httpReq.Header.Set(timestampHeader, timestamp)
+	httpReq.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
+
+// SigV4Signer signs requests with AWS Signature Version 4, as used by AWS AppSync with IAM
+// authorization. All headers of the request are signed, except for the headers that can be changed
+// by proxies.
+type SigV4Signer struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is the token of temporary credentials, if any.
+	SessionToken string
+	Region       string
+	// Service is the signing name of the service, like appsync.
+	Service string
+
+	now func() time.Time
+}
+
+// sigV4IgnoredHeaders are the headers that are not signed.
+var sigV4IgnoredHeaders = map[string]bool{
+	"Authorization":   true,
+	"User-Agent":      true,
+	"X-Amzn-Trace-Id": true,
+	"Expect":          true,
+	"Content-Length":  true,
+}
+
+// Sign sets the X-Amz-Date, X-Amz-Security-Token and Authorization headers of the http request.
+func (s *SigV4Signer) Sign(httpReq *http.Request, body []byte) error {
+	t := signingTime(s.now).UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	httpReq.Header.Set("X-Amz-Date", amzDate)
+	if s.SessionToken != "" {
+		httpReq.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	// Canonical headers.
+	host := httpReq.Host
+	if host == "" {
+		host = httpReq.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range httpReq.Header {
+		if sigV4IgnoredHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// Canonical query string.
+	query := httpReq.URL.Query()
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, awsEscape(name, false)+"="+awsEscape(value, false))
+		}
+	}
+	sort.Strings(params)
+
+	path := httpReq.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		httpReq.Method,
+		awsEscape(path, true),
+		strings.Join(params, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/" + s.Service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	httpReq.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsEscape percent-encodes all characters except for the unreserved characters, and the slashes
+// of a path.
+func awsEscape(s string, path bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || path && c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// signingTime returns the current time, or the time of now when it is set.
+func signingTime(now func() time.Time) time.Time {
+	if now != nil {
+		return now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,83 @@
+package gqlclient
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigV4Signer_Sign(t *testing.T) {
+	// Requests of the AWS Signature Version 4 test suite.
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		body          string
+		header        http.Header
+		sessionToken  string
+		authorization string
+	}{
+		{
+			name:   "GetVanilla",
+			method: http.MethodGet,
+			url:    "https://example.amazonaws.com/",
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:   "PostVanilla",
+			method: http.MethodPost,
+			url:    "https://example.amazonaws.com/",
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:   "GetVanillaQueryOrderKey",
+			method: http.MethodGet,
+			url:    "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:   "PostXWWWFormUrlencoded",
+			method: http.MethodPost,
+			url:    "https://example.amazonaws.com/",
+			body:   "Param1=value1",
+			header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, values := range tt.header {
+				httpReq.Header[name] = values
+			}
+			httpReq.Header.Set("User-Agent", "test")
+
+			s := &SigV4Signer{
+				AccessKeyID:     "AKIDEXAMPLE",
+				SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+				Region:          "us-east-1",
+				Service:         "service",
+				now: func() time.Time {
+					return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+				},
+			}
+			if err := sign(s, httpReq); err != nil {
+				t.Fatal(err)
+			}
+			if got := httpReq.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %v, want 20150830T123600Z", got)
+			}
+			if got := httpReq.Header.Get("Authorization"); got != tt.authorization {
+				t.Errorf("Authorization = %v, want %v", got, tt.authorization)
+			}
+		})
+	}
+}
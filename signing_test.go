@@ -0,0 +1,123 @@
+package gqlclient_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteSigning struct {
+	suite.Suite
+	httpClient *mocks.HTTPClient
+	requests   []*http.Request
+	bodies     [][]byte
+}
+
+func TestSuiteSigning(t *testing.T) {
+	s := SuiteSigning{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteSigning) SetupTest() {
+	s.requests, s.bodies = nil, nil
+	s.httpClient = new(mocks.HTTPClient)
+	s.httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(func(req *http.Request) *http.Response {
+			body, err := ioutil.ReadAll(req.Body)
+			s.Require().NoError(err)
+			s.requests = append(s.requests, req)
+			s.bodies = append(s.bodies, body)
+			return &http.Response{
+				Body:       ioutil.NopCloser(strings.NewReader(`{"data": {}}`)),
+				StatusCode: http.StatusOK,
+			}
+		}, nil)
+}
+
+func (s *SuiteSigning) TestSigner() {
+	var signed []byte
+	c := gql.NewClient("https://example.com/graphql",
+		gql.WithHTTPClient(s.httpClient),
+		gql.WithDefaultHeader("X-Client", "test"),
+		gql.WithSigner(gql.SignerFunc(func(httpReq *http.Request, body []byte) error {
+			// All headers are set before the request is signed.
+			s.Equal("test", httpReq.Header.Get("X-Client"))
+			s.Equal("1", httpReq.Header.Get("X-Request"))
+			signed = body
+			httpReq.Header.Set("X-Signature", "signature")
+			return nil
+		})))
+	s.Require().NoError(c.Do(gql.NewRequest(`{ viewer { name } }`, gql.WithHeader("X-Request", "1")), nil))
+	s.Require().Len(s.requests, 1)
+	s.JSONEq(`{"query": "{ viewer { name } }"}`, string(signed))
+	s.Equal(signed, s.bodies[0])
+	s.Equal("signature", s.requests[0].Header.Get("X-Signature"))
+
+	// The compressed body is signed.
+	c = gql.NewClient("https://example.com/graphql",
+		gql.WithHTTPClient(s.httpClient),
+		gql.WithRequestCompression("gzip", gql.GzipEncoding, 0),
+		gql.WithSigner(gql.SignerFunc(func(httpReq *http.Request, body []byte) error {
+			signed = body
+			return nil
+		})))
+	s.Require().NoError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+	s.Equal("gzip", s.requests[1].Header.Get("Content-Encoding"))
+	s.Equal(signed, s.bodies[1])
+
+	c = gql.NewClient("https://example.com/graphql",
+		gql.WithHTTPClient(s.httpClient),
+		gql.WithSigner(gql.SignerFunc(func(*http.Request, []byte) error {
+			return errors.New("no credentials")
+		})))
+	s.EqualError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil), "sign request: no credentials")
+	s.Len(s.requests, 2)
+}
+
+func (s *SuiteSigning) TestHMACSigner() {
+	key := []byte("secret")
+	c := gql.NewClient("https://example.com/graphql",
+		gql.WithHTTPClient(s.httpClient),
+		gql.WithSigner(&gql.HMACSigner{Key: key, Header: "X-Hub-Signature"}))
+	s.Require().NoError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+	s.Require().Len(s.requests, 1)
+
+	timestamp := s.requests[0].Header.Get("X-Timestamp")
+	s.NotEmpty(timestamp)
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp + "\nPOST\n/graphql\n"))
+	mac.Write(s.bodies[0])
+	s.Equal(hex.EncodeToString(mac.Sum(nil)), s.requests[0].Header.Get("X-Hub-Signature"))
+}
+
+func (s *SuiteSigning) TestSigV4Signer() {
+	c := gql.NewClient("https://example.appsync-api.eu-west-1.amazonaws.com/graphql",
+		gql.WithHTTPClient(s.httpClient),
+		gql.WithSigner(&gql.SigV4Signer{
+			AccessKeyID:     "AKIDEXAMPLE",
+			SecretAccessKey: "secret",
+			SessionToken:    "token",
+			Region:          "eu-west-1",
+			Service:         "appsync",
+		}))
+	s.Require().NoError(c.Do(gql.NewRequest(`{ viewer { name } }`), nil))
+	s.Require().Len(s.requests, 1)
+
+	header := s.requests[0].Header
+	s.Equal("token", header.Get("X-Amz-Security-Token"))
+	s.Regexp(`^AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/\d{8}/eu-west-1/appsync/aws4_request, `+
+		`SignedHeaders=accept;accept-encoding;content-type;host;x-amz-date;x-amz-security-token, Signature=[0-9a-f]{64}$`,
+		header.Get("Authorization"))
+}
@@ -0,0 +1,187 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TokenDecoder is a Decoder that can read a json value token by token, like json.Decoder. The
+// Decoder of the Codec must implement TokenDecoder to use Client.DoStream.
+type TokenDecoder interface {
+	Decoder
+	Token() (json.Token, error)
+	More() bool
+}
+
+// StreamFunc is called by Client.DoStream for every element of the streamed list. The element can
+// be decoded by calling Decode on the given Decoder once. Elements that are not decoded are skipped.
+// Returning an error stops the stream.
+type StreamFunc func(index int, elem Decoder) error
+
+// errInvalidStream is used when the response body does not have the structure of a GraphQL response.
+var errInvalidStream = errors.New("invalid response structure")
+
+// DoStream executes the Request and calls fn for every element of the list at the given path in the
+// response, without decoding the whole response in memory. The path is a dot separated list of
+// field names starting at the root of the response, for example "data.orders.edges". The errors
+// of the response are collected regardless of their position in the response and are returned
+// after the stream ended. With WithTokenSource, the request is only sent again with a new token
+// when the token is rejected with a 401 status code, as the response is not read in memory to
+// find an UNAUTHENTICATED error.
+//  err := client.DoStream(req, "data.orders.edges", func(i int, elem Decoder) error {
+//      var edge OrderEdge
+//      return elem.Decode(&edge)
+//  })
+func (c *Client) DoStream(req *Request, path string, fn StreamFunc) (err error) {
+	httpResp, err := c.send(req, c.header(req), true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := httpResp.Body.Close()
+		if cerr != nil && err == nil {
+			err = fmt.Errorf("close body: %w", cerr)
+		}
+	}()
+
+	dec, err := c.decodeOptionsFor(req).newDecoder(c.codec, httpResp.Body, false)
+	if err != nil {
+		return err
+	}
+	tokenDec, ok := dec.(TokenDecoder)
+	if !ok {
+		return fmt.Errorf("%w: Token", ErrDecoderOption)
+	}
+
+	s := &streamDecoder{dec: tokenDec, fn: fn}
+	if err := s.object(strings.Split(path, "."), true); err != nil {
+		var ferr *streamFuncError
+		if errors.As(err, &ferr) {
+			return ferr.err
+		}
+		return badResponseError(httpResp)
+	}
+
+	// Return the GraphQL errors, if any.
+	if len(s.errors) > 0 {
+		return s.errors
+	}
+	return nil
+}
+
+// streamFuncError wraps an error returned by a StreamFunc, to distinguish it from decoding errors.
+type streamFuncError struct {
+	err error
+}
+
+func (e *streamFuncError) Error() string {
+	return e.err.Error()
+}
+
+// streamDecoder walks a GraphQL response and calls fn for every element of the list at a path.
+type streamDecoder struct {
+	dec    TokenDecoder
+	fn     StreamFunc
+	errors ErrorList
+}
+
+// object reads a json object and descends into the field with the name of the first segment. When
+// root is set, the errors field is collected as well.
+func (s *streamDecoder) object(segments []string, root bool) error {
+	if ok, err := s.open('{'); !ok || err != nil {
+		return err
+	}
+	for s.dec.More() {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return errInvalidStream
+		}
+
+		switch {
+		case key == segments[0] && len(segments) == 1:
+			err = s.list()
+		case key == segments[0]:
+			err = s.object(segments[1:], false)
+		case key == "errors" && root:
+			err = s.dec.Decode(&s.errors)
+		default:
+			err = s.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return s.close('}')
+}
+
+// list reads a json list and calls fn for every element.
+func (s *streamDecoder) list() error {
+	if ok, err := s.open('['); !ok || err != nil {
+		return err
+	}
+	for i := 0; s.dec.More(); i++ {
+		elem := &elementDecoder{dec: s.dec}
+		if err := s.fn(i, elem); err != nil {
+			return &streamFuncError{err: err}
+		}
+		if !elem.decoded {
+			if err := s.skip(); err != nil {
+				return err
+			}
+		}
+	}
+	return s.close(']')
+}
+
+// open reads the opening delimiter of a json object or list. It returns false if the value is null.
+func (s *streamDecoder) open(delim json.Delim) (bool, error) {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return false, nil
+	}
+	if tok != delim {
+		return false, errInvalidStream
+	}
+	return true, nil
+}
+
+// close reads the closing delimiter of a json object or list.
+func (s *streamDecoder) close(delim json.Delim) error {
+	tok, err := s.dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return errInvalidStream
+	}
+	return nil
+}
+
+// skip reads and discards the next json value.
+func (s *streamDecoder) skip() error {
+	var discard json.RawMessage
+	return s.dec.Decode(&discard)
+}
+
+// elementDecoder decodes a single element of a streamed list.
+type elementDecoder struct {
+	dec     Decoder
+	decoded bool
+}
+
+func (d *elementDecoder) Decode(v interface{}) error {
+	if d.decoded {
+		return errors.New("element already decoded")
+	}
+	d.decoded = true
+	return d.dec.Decode(v)
+}
@@ -0,0 +1,136 @@
+package gqlclient_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+type SuiteStream struct {
+	suite.Suite
+}
+
+func TestSuiteStream(t *testing.T) {
+	s := SuiteStream{}
+	suite.Run(t, &s)
+}
+
+type streamEdge struct {
+	Node struct {
+		ID string
+	}
+}
+
+// client returns a Client that responds with the given body to every request.
+func (s *SuiteStream) client(body string) *gql.Client {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			StatusCode: http.StatusOK,
+		}, nil)
+	return gql.NewClient("test", gql.WithHTTPClient(httpClient))
+}
+
+func (s *SuiteStream) TestDoStream() {
+	c := s.client(`{"data": {
+		"total": 3,
+		"orders": {
+			"pageInfo": {"hasNextPage": false},
+			"edges": [{"node": {"id": "1"}}, {"node": {"id": "2"}}, {"node": {"id": "3"}}]
+		}
+	}}`)
+
+	var ids []string
+	err := c.DoStream(gql.NewRequest(""), "data.orders.edges", func(i int, elem gql.Decoder) error {
+		var edge streamEdge
+		if err := elem.Decode(&edge); err != nil {
+			return err
+		}
+		s.Equal(len(ids), i)
+		ids = append(ids, edge.Node.ID)
+		return nil
+	})
+	s.NoError(err)
+	s.Equal([]string{"1", "2", "3"}, ids)
+}
+
+func (s *SuiteStream) TestSkipElements() {
+	c := s.client(`{"data": {"orders": {"edges": [{"node": {"id": "1"}}, {"node": {"id": "2"}}]}}}`)
+
+	var ids []string
+	err := c.DoStream(gql.NewRequest(""), "data.orders.edges", func(i int, elem gql.Decoder) error {
+		if i == 0 {
+			return nil
+		}
+		var edge streamEdge
+		err := elem.Decode(&edge)
+		ids = append(ids, edge.Node.ID)
+		return err
+	})
+	s.NoError(err)
+	s.Equal([]string{"2"}, ids)
+}
+
+func (s *SuiteStream) TestErrorsBeforeData() {
+	c := s.client(`{
+		"errors": [{"message": "partial failure"}],
+		"data": {"orders": {"edges": [{"node": {"id": "1"}}]}}
+	}`)
+
+	calls := 0
+	err := c.DoStream(gql.NewRequest(""), "data.orders.edges", func(i int, elem gql.Decoder) error {
+		calls++
+		return nil
+	})
+	var gqlerrs gql.ErrorList
+	s.Require().ErrorAs(err, &gqlerrs)
+	s.Equal("partial failure", gqlerrs[0].Message)
+	s.Equal(1, calls)
+}
+
+func (s *SuiteStream) TestErrorsAfterData() {
+	c := s.client(`{
+		"data": {"orders": null},
+		"errors": [{"message": "not found", "path": ["orders"]}]
+	}`)
+
+	err := c.DoStream(gql.NewRequest(""), "data.orders.edges", func(i int, elem gql.Decoder) error {
+		s.Fail("unexpected element")
+		return nil
+	})
+	var gqlerrs gql.ErrorList
+	s.Require().ErrorAs(err, &gqlerrs)
+	s.Equal("not found", gqlerrs[0].Message)
+}
+
+func (s *SuiteStream) TestStreamFuncError() {
+	c := s.client(`{"data": {"orders": {"edges": [{"node": {"id": "1"}}, {"node": {"id": "2"}}]}}}`)
+
+	ferr := errors.New("stop")
+	calls := 0
+	err := c.DoStream(gql.NewRequest(""), "data.orders.edges", func(i int, elem gql.Decoder) error {
+		calls++
+		return ferr
+	})
+	s.ErrorIs(err, ferr)
+	s.Equal(1, calls)
+}
+
+func (s *SuiteStream) TestBadResponse() {
+	c := s.client(`{"data": {"orders": {"edges": {}}}}`)
+
+	err := c.DoStream(gql.NewRequest(""), "data.orders.edges", func(i int, elem gql.Decoder) error {
+		return nil
+	})
+	s.ErrorIs(err, gql.ErrBadResponse)
+}
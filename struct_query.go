@@ -0,0 +1,244 @@
+package gqlclient
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// NewQuery makes a new Request with a query that is generated from the type of v, which must be a
+// struct or a pointer to a struct. The response of the Request can be decoded into v.
+//
+// The fields of the struct are selected using their json name, or the lower camel case of their Go
+// name. Use the graphql tag to select a field with arguments, an alias or directives; the tag is
+// used as the selection. Use a "... on Type" tag on an embedded struct for an inline fragment.
+// Fields with a "-" tag are skipped.
+//  var q struct {
+//      User struct {
+//          Name   string
+//          Avatar string `graphql:"avatar(size: $size)"`
+//          Admin  `graphql:"... on Admin"`
+//      } `graphql:"user(id: $id)"`
+//  }
+//  req, err := NewQuery(&q, WithTypedVar("id", "ID!", id), WithVar("size", 64))
+//  err = client.Do(req, &q)
+//
+// The variable definitions of the query are taken from WithTypedVar, or are derived from the Go
+// types of the values for strings, booleans, numbers, pointers and slices. Use WithTypedVar for
+// int64, uint32, uint64 and []byte values, which do not fit in an Int or a list of them.
+func NewQuery(v interface{}, opts ...RequestOption) (*Request, error) {
+	return newStructRequest("query", v, opts)
+}
+
+// NewMutation makes a new Request with a mutation that is generated from the type of v, like
+// NewQuery.
+//  req, err := NewMutation(&m, WithTypedVar("input", "UserInput!", input))
+func NewMutation(v interface{}, opts ...RequestOption) (*Request, error) {
+	return newStructRequest("mutation", v, opts)
+}
+
+// WithTypedVar defines the value and the GraphQL type of a variable in a query that is generated by
+// NewQuery or NewMutation.
+//  NewQuery(&q, WithTypedVar("id", "ID!", id))
+func WithTypedVar(name, typ string, value interface{}) RequestOption {
+	return func(r *Request) {
+		if r.varTypes == nil {
+			r.varTypes = make(map[string]string)
+		}
+		r.varTypes[name] = typ
+		r.Variables[name] = value
+	}
+}
+
+func newStructRequest(operation string, v interface{}, opts []RequestOption) (*Request, error) {
+	req := NewRequest("", opts...)
+
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("generate %s: %T is not a struct", operation, v)
+	}
+
+	var b strings.Builder
+	b.WriteString(operation)
+	if len(req.Variables) > 0 {
+		definitions := make([]string, 0, len(req.Variables))
+		for _, name := range sortedKeys(req.Variables) {
+			typ, ok := req.varTypes[name]
+			if !ok {
+				if typ, ok = variableType(reflect.TypeOf(req.Variables[name])); !ok {
+					return nil, fmt.Errorf("generate %s: cannot derive the type of $%s, use WithTypedVar", operation, name)
+				}
+			}
+			definitions = append(definitions, fmt.Sprintf("$%s: %s", name, typ))
+		}
+		fmt.Fprintf(&b, " (%s)", strings.Join(definitions, ", "))
+	}
+	b.WriteString(" ")
+	if err := writeSelectionSet(&b, t, map[reflect.Type]bool{}); err != nil {
+		return nil, fmt.Errorf("generate %s: %w", operation, err)
+	}
+	req.Query = b.String()
+	return req, nil
+}
+
+// writeSelectionSet writes the selection set for the fields of a struct type. The types that are
+// being written are tracked to detect recursive types.
+func writeSelectionSet(b *strings.Builder, t reflect.Type, visiting map[reflect.Type]bool) error {
+	if visiting[t] {
+		return fmt.Errorf("recursive type %s", t)
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	b.WriteString("{")
+	if err := writeFields(b, t, visiting); err != nil {
+		return err
+	}
+	b.WriteString(" }")
+	return nil
+}
+
+// writeFields writes the selections for the fields of a struct type, including the fields of
+// embedded structs.
+func writeFields(b *strings.Builder, t reflect.Type, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, hasTag := f.Tag.Lookup("graphql")
+		jsonName, jsonOpts := parseJSONTag(f.Tag.Get("json"))
+		if f.PkgPath != "" && !f.Anonymous || tag == "-" || jsonName == "-" && jsonOpts == "" {
+			continue
+		}
+		ft := selectionType(f.Type)
+
+		if f.Anonymous && !hasTag && jsonName == "" && ft.Kind() == reflect.Struct {
+			// The fields of embedded structs are decoded as fields of the outer struct.
+			if err := writeFields(b, ft, visiting); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b.WriteString(" ")
+		if strings.HasPrefix(tag, "...") {
+			b.WriteString(tag)
+		} else {
+			b.WriteString(fieldSelection(f, tag, jsonName))
+		}
+		if ft.Kind() == reflect.Struct && !isLeafType(ft) {
+			b.WriteString(" ")
+			if err := writeSelectionSet(b, ft, visiting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fieldSelection returns the selection for a struct field. An alias is added when the name of the
+// selected field does not match the name that the field is decoded from.
+func fieldSelection(f reflect.StructField, tag, jsonName string) string {
+	if tag == "" {
+		if jsonName != "" {
+			return jsonName
+		}
+		return lowerCamelCase(f.Name)
+	}
+
+	name := tag
+	if i := strings.IndexAny(name, "(@ {"); i >= 0 {
+		name = name[:i]
+	}
+	if strings.Contains(name, ":") {
+		// The selection has an alias already.
+		return tag
+	}
+	switch {
+	case jsonName != "" && name != jsonName:
+		return jsonName + ": " + tag
+	case jsonName == "" && !strings.EqualFold(name, f.Name):
+		return lowerCamelCase(f.Name) + ": " + tag
+	}
+	return tag
+}
+
+// parseJSONTag returns the name and the options of a json tag.
+func parseJSONTag(tag string) (string, string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// selectionType returns the type that the selection set of a field is generated for, which is the
+// element type of pointers, slices and arrays.
+func selectionType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array:
+			if isLeafType(t) {
+				return t
+			}
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+// isLeafType reports whether a type decodes itself and therefore has no selection set.
+func isLeafType(t reflect.Type) bool {
+	return t.Implements(unmarshalerType) || reflect.PtrTo(t).Implements(unmarshalerType) ||
+		t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// lowerCamelCase returns the name with its leading upper case letters in lower case, keeping the
+// last one in upper case when it starts a word: "ID" becomes "id" and "URLPath" becomes "urlPath".
+func lowerCamelCase(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// variableType derives the GraphQL type of a variable from the Go type of its value.
+func variableType(t reflect.Type) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		typ, ok := variableType(t.Elem())
+		return strings.TrimSuffix(typ, "!"), ok
+	case reflect.Slice, reflect.Array:
+		// A []byte is encoded as a base64 string, which has no built-in type.
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "", false
+		}
+		typ, ok := variableType(t.Elem())
+		return "[" + typ + "]!", ok
+	case reflect.String:
+		return "String!", true
+	case reflect.Bool:
+		return "Boolean!", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		// Int is a 32-bit integer. Values of the int type are expected to fit, larger types are not.
+		return "Int!", true
+	case reflect.Float32, reflect.Float64:
+		return "Float!", true
+	}
+	return "", false
+}
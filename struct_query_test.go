@@ -0,0 +1,172 @@
+package gqlclient_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	gql "github.com/weavedev/go-gqlclient"
+)
+
+var structQuerySchema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	scalar Time
+	type Query {
+		user(id: ID!): User
+		node(id: ID!): Node
+	}
+	type Mutation {
+		rename(id: ID!, name: String!): User!
+	}
+	interface Node {
+		id: ID!
+	}
+	type User implements Node {
+		id: ID!
+		name: String!
+		avatar(size: Int): String
+		createdAt: Time!
+		friends(first: Int): [User!]!
+	}
+	type Post implements Node {
+		id: ID!
+		title: String!
+	}
+`})
+
+type SuiteStructQuery struct {
+	suite.Suite
+}
+
+func TestSuiteStructQuery(t *testing.T) {
+	s := SuiteStructQuery{}
+	suite.Run(t, &s)
+}
+
+// assertValid checks that the generated query is valid and that its response can be decoded into v.
+func (s *SuiteStructQuery) assertValid(req *gql.Request, v interface{}) {
+	_, errs := gqlparser.LoadQuery(structQuerySchema, req.Query)
+	s.Require().Empty(errs, req.Query)
+	s.NoError(gql.CheckShape(structQuerySchema, req.Query, v))
+}
+
+func (s *SuiteStructQuery) TestNewQuery() {
+	type friend struct {
+		Name string
+	}
+	var q struct {
+		User *struct {
+			ID        string `json:"id"`
+			Name      string
+			Avatar    *string   `graphql:"avatar(size: $size)"`
+			CreatedAt time.Time `json:"createdAt"`
+			Friends   []friend  `graphql:"friends(first: 10)"`
+			ignored   string
+			Skipped   string `graphql:"-"`
+		} `graphql:"user(id: $id)"`
+	}
+
+	req, err := gql.NewQuery(&q, gql.WithTypedVar("id", "ID!", "1"), gql.WithVar("size", 64))
+	s.Require().NoError(err)
+	s.Equal("query ($id: ID!, $size: Int!) {"+
+		" user(id: $id) { id name avatar(size: $size) createdAt friends(first: 10) { name } } }", req.Query)
+	s.Equal(map[string]interface{}{"id": "1", "size": 64}, req.Variables)
+	s.assertValid(req, &q)
+}
+
+func (s *SuiteStructQuery) TestNewQueryAliases() {
+	var q struct {
+		Me *struct {
+			Name string
+		} `graphql:"user(id: 1)"`
+		Other *struct {
+			Name string
+		} `json:"other" graphql:"user(id: 2)"`
+		Third *struct {
+			Name string
+		} `graphql:"third: user(id: 3) @include(if: true)"`
+	}
+
+	req, err := gql.NewQuery(q)
+	s.Require().NoError(err)
+	s.Equal("query { me: user(id: 1) { name } other: user(id: 2) { name }"+
+		" third: user(id: 3) @include(if: true) { name } }", req.Query)
+	s.assertValid(req, &q)
+}
+
+func (s *SuiteStructQuery) TestNewQueryInlineFragments() {
+	type node struct {
+		ID string `json:"id"`
+	}
+	type user struct {
+		Name string
+	}
+	type post struct {
+		Title string
+	}
+	var q struct {
+		Node *struct {
+			node
+			user `graphql:"... on User"`
+			post `graphql:"... on Post"`
+		} `graphql:"node(id: $id)"`
+	}
+
+	req, err := gql.NewQuery(&q, gql.WithTypedVar("id", "ID!", "1"))
+	s.Require().NoError(err)
+	s.Equal("query ($id: ID!) { node(id: $id) { id ... on User { name } ... on Post { title } } }", req.Query)
+	s.assertValid(req, &q)
+}
+
+func (s *SuiteStructQuery) TestNewMutation() {
+	var m struct {
+		Rename struct {
+			ID string `json:"id"`
+		} `graphql:"rename(id: $id, name: $name)"`
+	}
+
+	req, err := gql.NewMutation(&m, gql.WithTypedVar("id", "ID!", "1"), gql.WithVar("name", "Bob"))
+	s.Require().NoError(err)
+	s.Equal("mutation ($id: ID!, $name: String!) { rename(id: $id, name: $name) { id } }", req.Query)
+	s.assertValid(req, &m)
+}
+
+func (s *SuiteStructQuery) TestVariableTypes() {
+	name := "Bob"
+	var q struct {
+		Name string
+	}
+	req, err := gql.NewQuery(&q,
+		gql.WithVar("a", &name),
+		gql.WithVar("b", []int{1}),
+		gql.WithVar("c", []*float64{nil}),
+		gql.WithVar("d", true),
+	)
+	s.Require().NoError(err)
+	s.Equal("query ($a: String, $b: [Int!]!, $c: [Float]!, $d: Boolean!) { name }", req.Query)
+
+	_, err = gql.NewQuery(&q, gql.WithVar("e", struct{}{}))
+	s.EqualError(err, "generate query: cannot derive the type of $e, use WithTypedVar")
+
+	// Integers that do not fit in an Int and bytes have no derived type.
+	for _, value := range []interface{}{int64(1), uint32(1), uint64(1), []byte("data")} {
+		_, err = gql.NewQuery(&q, gql.WithVar("f", value))
+		s.EqualError(err, "generate query: cannot derive the type of $f, use WithTypedVar", "%T", value)
+	}
+	req, err = gql.NewQuery(&q, gql.WithTypedVar("f", "ID!", int64(1)), gql.WithVar("g", int32(1)))
+	s.Require().NoError(err)
+	s.Equal("query ($f: ID!, $g: Int!) { name }", req.Query)
+}
+
+func (s *SuiteStructQuery) TestInvalidTypes() {
+	_, err := gql.NewQuery("query")
+	s.EqualError(err, "generate query: string is not a struct")
+
+	type recursive struct {
+		Friends []recursive
+	}
+	_, err = gql.NewQuery(&recursive{})
+	s.EqualError(err, "generate query: recursive type gqlclient_test.recursive")
+}
@@ -0,0 +1,120 @@
+package gqlclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+// ErrUntrustedDocument is returned when the query of a Request is not in the manifest of trusted
+// documents. The Request is not sent.
+var ErrUntrustedDocument = errors.New("query is not a trusted document")
+
+// Manifest maps the queries of trusted documents to their ids. Queries are matched using their
+// normalized form, so the whitespace, comments and order of definitions of a query do not have to
+// match the document in the manifest.
+type Manifest struct {
+	ids map[string]string
+}
+
+// NewManifest makes a new Manifest from a map of document ids to queries. Every query must be
+// valid, and queries with the same normalized form must have the same id.
+func NewManifest(documents map[string]string) (*Manifest, error) {
+	m := &Manifest{ids: make(map[string]string, len(documents))}
+	for _, id := range sortedKeys(documents) {
+		normalized, err := NormalizeQuery(documents[id])
+		if err != nil {
+			return nil, fmt.Errorf("document %s: %w", id, err)
+		}
+		if other, ok := m.ids[normalized]; ok {
+			return nil, fmt.Errorf("documents %s and %s have the same query", other, id)
+		}
+		m.ids[normalized] = id
+	}
+	return m, nil
+}
+
+// ParseManifest parses a manifest from a json object of document ids to queries, as written by
+// "gqlclient-gen manifest".
+//  {"0c3f...": "query GetUser($id:ID!){user(id:$id){name}}"}
+func ParseManifest(data []byte) (*Manifest, error) {
+	var documents map[string]string
+	if err := json.Unmarshal(data, &documents); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	return NewManifest(documents)
+}
+
+// LoadManifest loads a manifest from a json file, like ParseManifest.
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(data)
+}
+
+// DocumentID returns the id of the trusted document for the query.
+func (m *Manifest) DocumentID(query string) (string, bool) {
+	normalized, err := NormalizeQuery(query)
+	if err != nil {
+		return "", false
+	}
+	id, ok := m.ids[normalized]
+	return id, ok
+}
+
+// DocumentIDMode is the way that the id of a trusted document is sent instead of the query.
+type DocumentIDMode int
+
+const (
+	// SendDocumentID sends the id in the documentId field of the request.
+	SendDocumentID DocumentIDMode = iota
+	// SendPersistedQuery sends the id as the sha256Hash of the persistedQuery extension of the
+	// request, which must be the hash of the query, like the ids that are written by
+	// "gqlclient-gen manifest".
+	SendPersistedQuery
+)
+
+// trustedDocuments replaces the queries of requests with the ids of trusted documents.
+type trustedDocuments struct {
+	manifest *Manifest
+	mode     DocumentIDMode
+}
+
+// apply sets the id of the trusted document for the query of the Request, and removes the query.
+func (t *trustedDocuments) apply(req *Request, q *cachedQuery) error {
+	normalized, err := q.normalize()
+	if err != nil {
+		return ErrUntrustedDocument
+	}
+	id, ok := t.manifest.ids[normalized]
+	if !ok {
+		return ErrUntrustedDocument
+	}
+	req.Query = ""
+	switch t.mode {
+	case SendPersistedQuery:
+		extensions := make(map[string]interface{}, len(req.Extensions)+1)
+		for key, value := range req.Extensions {
+			extensions[key] = value
+		}
+		extensions["persistedQuery"] = map[string]interface{}{"version": 1, "sha256Hash": id}
+		req.Extensions = extensions
+	default:
+		req.DocumentID = id
+	}
+	return nil
+}
+
+// WithTrustedDocuments sends only the ids of trusted documents from the manifest instead of queries,
+// for servers that only accept registered operations. Requests with a query that is not in the
+// manifest are not sent and return ErrUntrustedDocument.
+//  manifest, err := LoadManifest("manifest.json")
+//  NewClient(endpoint, WithTrustedDocuments(manifest, SendDocumentID))
+func WithTrustedDocuments(manifest *Manifest, mode DocumentIDMode) ClientOption {
+	return func(client *Client) {
+		client.trusted = &trustedDocuments{manifest: manifest, mode: mode}
+	}
+}
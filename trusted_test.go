@@ -0,0 +1,93 @@
+package gqlclient_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+const manifestJSON = `{
+	"user": "query GetUser($id:ID!){user(id:$id){...UserFields}}fragment UserFields on User{name}",
+	"rename": "mutation Rename($id:ID!,$name:String!){rename(id:$id,name:$name){id}}"
+}`
+
+type SuiteTrusted struct {
+	suite.Suite
+}
+
+func TestSuiteTrusted(t *testing.T) {
+	s := SuiteTrusted{}
+	suite.Run(t, &s)
+}
+
+// client returns a client with the manifest that expects a request with the body.
+func (s *SuiteTrusted) client(mode gql.DocumentIDMode, body string) (*gql.Client, *mocks.HTTPClient) {
+	manifest, err := gql.ParseManifest([]byte(manifestJSON))
+	s.Require().NoError(err)
+
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.MatchedBy(func(req *http.Request) bool {
+			b, err := ioutil.ReadAll(req.Body)
+			return err == nil && string(b) == body+"\n"
+		})).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data": {}}`)),
+			StatusCode: http.StatusOK,
+		}, nil)
+	return gql.NewClient("test", gql.WithHTTPClient(httpClient), gql.WithTrustedDocuments(manifest, mode)), httpClient
+}
+
+func (s *SuiteTrusted) TestSendDocumentID() {
+	c, httpClient := s.client(gql.SendDocumentID, `{"variables":{"id":"1"},"documentId":"user"}`)
+
+	// The query matches the document in the manifest by its normalized form.
+	err := c.Do(gql.NewRequest(`
+		fragment UserFields on User { name }
+		query GetUser($id: ID!) {
+			user(id: $id) { ...UserFields }
+		}`, gql.WithVar("id", "1")), nil)
+	s.NoError(err)
+	httpClient.AssertExpectations(s.T())
+}
+
+func (s *SuiteTrusted) TestSendPersistedQuery() {
+	c, httpClient := s.client(gql.SendPersistedQuery,
+		`{"variables":{"id":"1","name":"Bob"},"extensions":{"persistedQuery":{"sha256Hash":"rename","version":1}}}`)
+
+	err := c.Do(gql.NewRequest(`mutation Rename($id: ID!, $name: String!) { rename(id: $id, name: $name) { id } }`,
+		gql.WithVar("id", "1"), gql.WithVar("name", "Bob")), nil)
+	s.NoError(err)
+	httpClient.AssertExpectations(s.T())
+}
+
+func (s *SuiteTrusted) TestUntrustedDocument() {
+	c, httpClient := s.client(gql.SendDocumentID, "")
+
+	for _, query := range []string{`query GetUser($id: ID!) { user(id: $id) { id } }`, `query {`} {
+		err := c.Do(gql.NewRequest(query, gql.WithVar("id", "1")), nil)
+		s.True(errors.Is(err, gql.ErrUntrustedDocument))
+	}
+	httpClient.AssertNotCalled(s.T(), "Do", mock.Anything)
+}
+
+func (s *SuiteTrusted) TestManifestErrors() {
+	_, err := gql.NewManifest(map[string]string{"a": "query {"})
+	s.Require().Error(err)
+	s.Contains(err.Error(), "document a: query validation: ")
+
+	_, err = gql.NewManifest(map[string]string{"a": "{ a }", "b": "query { a }"})
+	s.EqualError(err, "documents a and b have the same query")
+
+	_, err = gql.ParseManifest([]byte(`["query { a }"]`))
+	s.Require().Error(err)
+	s.Contains(err.Error(), "decode manifest: ")
+}
@@ -0,0 +1,26 @@
+package gqlclient
+
+import (
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// queryValidator validates queries against a schema.
+type queryValidator struct {
+	schema *ast.Schema
+}
+
+// validate validates the query, and returns the validated document.
+func (v *queryValidator) validate(q *cachedQuery) (*ast.QueryDocument, error) {
+	return q.validate(v.schema)
+}
+
+// WithSchema validates every Request against the schema before it is sent. Invalid queries are
+// not sent and return a *ValidationError. The validation results are cached in the query cache of
+// the Client. The variables are checked against the variable definitions of the operation, and
+// invalid variables return a *VariablesError.
+//  NewClient(endpoint, WithSchema(schema))
+func WithSchema(schema *ast.Schema) ClientOption {
+	return func(client *Client) {
+		client.validator = &queryValidator{schema: schema}
+	}
+}
@@ -0,0 +1,104 @@
+package gqlclient_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+var validationSchema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Query {
+		user(id: ID!): User
+	}
+	type User {
+		id: ID!
+		name: String
+	}
+`})
+
+type SuiteValidation struct {
+	suite.Suite
+}
+
+func TestSuiteValidation(t *testing.T) {
+	s := SuiteValidation{}
+	suite.Run(t, &s)
+}
+
+func (s *SuiteValidation) TestValidQuery() {
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data": {}}`)),
+			StatusCode: http.StatusOK,
+		}, nil)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient), gql.WithSchema(validationSchema))
+	err := c.Do(gql.NewRequest(`query ($id: ID!) { user(id: $id) { name } }`,
+		gql.WithVar("id", "1")), nil)
+	httpClient.AssertExpectations(s.T())
+	s.NoError(err)
+}
+
+func (s *SuiteValidation) TestInvalidQuery() {
+	httpClient := new(mocks.HTTPClient)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient), gql.WithSchema(validationSchema))
+	query := `query ($id: ID!) {
+		user(id: $id) {
+			nmae
+		}
+	}`
+	// Do the request twice, to validate from cache the second time.
+	for i := 0; i < 2; i++ {
+		err := c.Do(gql.NewRequest(query), nil)
+
+		var verr *gql.ValidationError
+		s.Require().ErrorAs(err, &verr)
+		s.Require().Len(verr.Errors, 1)
+		s.Contains(verr.Errors[0].Message, "nmae")
+		s.Equal(3, verr.Errors[0].Locations[0].Line)
+		s.Contains(err.Error(), "3:4:")
+	}
+	httpClient.AssertNotCalled(s.T(), "Do", mock.Anything)
+}
+
+func (s *SuiteValidation) TestInvalidNormalizedQuery() {
+	httpClient := new(mocks.HTTPClient)
+
+	// The query is validated as written, before it is normalized.
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient), gql.WithSchema(validationSchema),
+		gql.WithQueryNormalization())
+	err := c.Do(gql.NewRequest(`query ($id: ID!) {
+		user(id: $id) {
+			nmae
+		}
+	}`), nil)
+
+	var verr *gql.ValidationError
+	s.Require().ErrorAs(err, &verr)
+	s.Require().Len(verr.Errors, 1)
+	s.Equal(3, verr.Errors[0].Locations[0].Line)
+	httpClient.AssertNotCalled(s.T(), "Do", mock.Anything)
+}
+
+func (s *SuiteValidation) TestSyntaxError() {
+	httpClient := new(mocks.HTTPClient)
+
+	c := gql.NewClient("test", gql.WithHTTPClient(httpClient), gql.WithSchema(validationSchema))
+	_, err := c.DoRaw(gql.NewRequest(`query {`))
+
+	var verr *gql.ValidationError
+	s.ErrorAs(err, &verr)
+	httpClient.AssertNotCalled(s.T(), "Do", mock.Anything)
+}
@@ -0,0 +1,400 @@
+package gqlclient
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// ScalarEncoder converts the value of a variable of a custom scalar type into a value that the
+// Codec encodes as expected by the server.
+type ScalarEncoder func(value interface{}) (interface{}, error)
+
+// VariableError describes a problem with the value of a variable.
+type VariableError struct {
+	// Path to the value, starting with the name of the variable.
+	Path    ast.Path
+	Message string
+}
+
+func (e VariableError) Error() string {
+	return fmt.Sprintf("$%s: %s", e.Path.String(), e.Message)
+}
+
+// VariablesError is used when the variables of a Request do not match the variable definitions of
+// the operation. It contains all the problems that were found.
+type VariablesError struct {
+	Errors []VariableError
+}
+
+func (e *VariablesError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("invalid variables: %s", strings.Join(messages, "; "))
+}
+
+// WithScalarEncoder sets the ScalarEncoder for variables of a custom scalar type. It is used when
+// the variables are checked against the schema that is set using WithSchema.
+//  NewClient(endpoint, WithSchema(schema), WithScalarEncoder("DateTime", encodeTime))
+func WithScalarEncoder(typeName string, encoder ScalarEncoder) ClientOption {
+	return func(client *Client) {
+		if client.scalarEncoders == nil {
+			client.scalarEncoders = make(map[string]ScalarEncoder)
+		}
+		client.scalarEncoders[typeName] = encoder
+	}
+}
+
+// variableCoercer checks variables against the variable definitions of an operation, and converts
+// them into plain values with the ScalarEncoders applied.
+type variableCoercer struct {
+	schema   *ast.Schema
+	encoders map[string]ScalarEncoder
+	codec    Codec
+	errs     []VariableError
+}
+
+// coerceVariables checks the variables against the variable definitions of the operation and
+// returns the coerced variables. All problems are returned at once in a *VariablesError.
+func (c *variableCoercer) coerceVariables(op *ast.OperationDefinition, variables map[string]interface{}) (map[string]interface{}, error) {
+	coerced := make(map[string]interface{}, len(variables))
+	for _, name := range sortedKeys(variables) {
+		if op.VariableDefinitions.ForName(name) == nil {
+			c.errorf(ast.Path{ast.PathName(name)}, "variable is not defined by operation %s", operationName(op))
+		}
+	}
+	for _, def := range op.VariableDefinitions {
+		path := ast.Path{ast.PathName(def.Variable)}
+		value, ok := variables[def.Variable]
+		if !ok {
+			if def.Type.NonNull && def.DefaultValue == nil {
+				c.errorf(path, "must be defined")
+			}
+			continue
+		}
+		coerced[def.Variable] = c.coerce(path, def.Type, reflect.ValueOf(value))
+	}
+
+	if len(c.errs) > 0 {
+		return nil, &VariablesError{Errors: c.errs}
+	}
+	return coerced, nil
+}
+
+func (c *variableCoercer) errorf(path ast.Path, format string, args ...interface{}) {
+	c.errs = append(c.errs, VariableError{
+		Path:    append(ast.Path{}, path...),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// coerce checks the value against the type and returns the coerced value.
+func (c *variableCoercer) coerce(path ast.Path, typ *ast.Type, v reflect.Value) interface{} {
+	v = indirect(v)
+	if !v.IsValid() {
+		if typ.NonNull {
+			c.errorf(path, "must not be null")
+		}
+		return nil
+	}
+
+	if typ.Elem != nil {
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			// A single value is coerced into a list with one element.
+			return []interface{}{c.coerce(path, typ.Elem, v)}
+		}
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = c.coerce(append(path, ast.PathIndex(i)), typ.Elem, v.Index(i))
+		}
+		return list
+	}
+
+	def := c.schema.Types[typ.NamedType]
+	if def == nil {
+		c.errorf(path, "unknown type %s", typ.NamedType)
+		return nil
+	}
+	if encoder, ok := c.encoders[def.Name]; ok {
+		value, err := encoder(v.Interface())
+		if err != nil {
+			c.errorf(path, "encode %s: %s", def.Name, err)
+		}
+		return value
+	}
+	if def.Kind != ast.Scalar || builtinScalar(def.Name) {
+		// Normalize values with a custom json or text encoding before checking them, like a UUID
+		// that is sent as a string. Values of custom scalar types are sent as is.
+		if customEncoding(v) {
+			var err error
+			if v, err = c.normalize(v); err != nil {
+				c.errorf(path, "%s", err)
+				return nil
+			}
+			return c.coerce(path, typ, v)
+		}
+	}
+
+	switch def.Kind {
+	case ast.Scalar:
+		return c.coerceScalar(path, def, v)
+	case ast.Enum:
+		return c.coerceEnum(path, def, v)
+	case ast.InputObject:
+		return c.coerceInputObject(path, def, v)
+	default:
+		c.errorf(path, "%s is not an input type", def.Name)
+		return nil
+	}
+}
+
+// coerceScalar checks the value of a built-in scalar type. Values of custom scalar types are not
+// checked.
+func (c *variableCoercer) coerceScalar(path ast.Path, def *ast.Definition, v reflect.Value) interface{} {
+	value := v.Interface()
+	var ok bool
+	switch def.Name {
+	case "Int":
+		ok = isInt(v)
+	case "Float":
+		ok = isInteger(v) || v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64
+		if n, isNumber := value.(json.Number); isNumber {
+			_, err := n.Float64()
+			ok = err == nil
+		}
+	case "String":
+		ok = v.Kind() == reflect.String && !isNumber(value)
+	case "Boolean":
+		ok = v.Kind() == reflect.Bool
+	case "ID":
+		ok = isInteger(v) || v.Kind() == reflect.String && !isNumber(value)
+	default:
+		return value
+	}
+	if !ok {
+		c.errorf(path, "%s is not a valid %s", describe(v), def.Name)
+	}
+	return value
+}
+
+// coerceEnum checks that the value is one of the values of the enum.
+func (c *variableCoercer) coerceEnum(path ast.Path, def *ast.Definition, v reflect.Value) interface{} {
+	if v.Kind() != reflect.String {
+		c.errorf(path, "%s is not a valid %s", describe(v), def.Name)
+		return v.Interface()
+	}
+	if def.EnumValues.ForName(v.String()) == nil {
+		c.errorf(path, "%q is not a valid %s", v.String(), def.Name)
+	}
+	return v.Interface()
+}
+
+// coerceInputObject checks the fields of a map or struct against the fields of the input object.
+func (c *variableCoercer) coerceInputObject(path ast.Path, def *ast.Definition, v reflect.Value) interface{} {
+	fields := make(map[string]reflect.Value)
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			c.errorf(path, "%s is not a valid %s", describe(v), def.Name)
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			fields[iter.Key().String()] = iter.Value()
+		}
+	case reflect.Struct:
+		for _, f := range jsonFields(v.Type()) {
+			fv, ok := fieldByIndex(v, f.index)
+			if !ok || f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			fields[f.name] = fv
+		}
+	default:
+		c.errorf(path, "%s is not a valid %s", describe(v), def.Name)
+		return nil
+	}
+
+	object := make(map[string]interface{}, len(fields))
+	for _, name := range sortedKeys(fields) {
+		if def.Fields.ForName(name) == nil {
+			c.errorf(append(path, ast.PathName(name)), "unknown field of %s", def.Name)
+		}
+	}
+	for _, field := range def.Fields {
+		fieldPath := append(path, ast.PathName(field.Name))
+		value, ok := fields[field.Name]
+		if !ok {
+			if field.Type.NonNull && field.DefaultValue == nil {
+				c.errorf(fieldPath, "must be defined")
+			}
+			continue
+		}
+		object[field.Name] = c.coerce(fieldPath, field.Type, value)
+	}
+	return object
+}
+
+// normalize encodes the value with the Codec and decodes it into a plain value.
+func (c *variableCoercer) normalize(v reflect.Value) (reflect.Value, error) {
+	data, err := c.codec.Marshal(v.Interface())
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("encode value: %w", err)
+	}
+	var value interface{}
+	dec := c.codec.NewDecoder(bytes.NewReader(data))
+	if d, ok := dec.(interface{ UseNumber() }); ok {
+		d.UseNumber()
+	}
+	if err := dec.Decode(&value); err != nil {
+		return reflect.Value{}, fmt.Errorf("decode value: %w", err)
+	}
+	return reflect.ValueOf(value), nil
+}
+
+// sortedKeys returns the keys of a map with string keys in sorted order.
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = key.String()
+	}
+	sort.Strings(names)
+	return names
+}
+
+// indirect dereferences pointers and interfaces. It returns the zero Value for nil.
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// fieldByIndex returns the nested struct field, and false if an embedded pointer is nil.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, idx := range index {
+		if i > 0 {
+			if v = indirect(v); !v.IsValid() {
+				return reflect.Value{}, false
+			}
+		}
+		v = v.Field(idx)
+	}
+	return v, true
+}
+
+// isInt reports whether the value is an integer that fits in a 32-bit GraphQL Int.
+func isInt(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() >= math.MinInt32 && v.Int() <= math.MaxInt32
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() <= math.MaxInt32
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return f == math.Trunc(f) && f >= math.MinInt32 && f <= math.MaxInt32
+	case reflect.String:
+		if n, ok := v.Interface().(json.Number); ok {
+			i, err := strconv.ParseInt(n.String(), 10, 32)
+			return err == nil && i == int64(int32(i))
+		}
+	}
+	return false
+}
+
+// isInteger reports whether the value is an integer of any size, which are valid Float and ID
+// values.
+func isInteger(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		return f == math.Trunc(f) && !math.IsInf(f, 0)
+	case reflect.String:
+		if n, ok := v.Interface().(json.Number); ok {
+			_, err := strconv.ParseInt(n.String(), 10, 64)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// builtinScalar reports whether the scalar type is one of the built-in scalar types.
+func builtinScalar(name string) bool {
+	switch name {
+	case "Int", "Float", "String", "Boolean", "ID":
+		return true
+	}
+	return false
+}
+
+// customEncoding reports whether the value is encoded by its own MarshalJSON or MarshalText method.
+func customEncoding(v reflect.Value) bool {
+	switch v.Interface().(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return true
+	}
+	return false
+}
+
+// isNumber reports whether the value is a json.Number, which has a string kind.
+func isNumber(value interface{}) bool {
+	_, ok := value.(json.Number)
+	return ok
+}
+
+// isEmptyValue reports whether the value is empty, as defined by the omitempty option of
+// encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// describe returns a short description of a value for error messages.
+func describe(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+		return v.Type().String()
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// operationName returns the name of the operation for error messages.
+func operationName(op *ast.OperationDefinition) string {
+	if op.Name == "" {
+		return "(anonymous)"
+	}
+	return op.Name
+}
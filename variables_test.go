@@ -0,0 +1,229 @@
+package gqlclient_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	gql "github.com/weavedev/go-gqlclient"
+	"github.com/weavedev/go-gqlclient/mocks"
+)
+
+var variablesSchema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	scalar DateTime
+
+	enum Role { ADMIN USER }
+
+	input UserFilter {
+		role: Role!
+		ids: [ID!]
+		createdAfter: DateTime
+		limit: Int = 10
+	}
+
+	type Query {
+		users(filter: UserFilter!, first: Int): [User!]!
+		search(ids: [ID!], id: ID, price: Float, name: String): [User!]!
+	}
+
+	type User {
+		id: ID!
+	}
+`})
+
+const usersQuery = `query Users($filter: UserFilter!, $first: Int) { users(filter: $filter, first: $first) { id } }`
+
+type SuiteVariables struct {
+	suite.Suite
+}
+
+func TestSuiteVariables(t *testing.T) {
+	s := SuiteVariables{}
+	suite.Run(t, &s)
+}
+
+// do sends the request and returns the decoded variables of the http request body.
+func (s *SuiteVariables) do(req *gql.Request, opts ...gql.ClientOption) (map[string]interface{}, error) {
+	var variables map[string]interface{}
+	httpClient := new(mocks.HTTPClient)
+	httpClient.
+		On("Do", mock.AnythingOfType("*http.Request")).
+		Run(func(args mock.Arguments) {
+			var body struct {
+				Variables map[string]interface{}
+			}
+			s.Require().NoError(json.NewDecoder(args.Get(0).(*http.Request).Body).Decode(&body))
+			variables = body.Variables
+		}).
+		Return(&http.Response{
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data": {}}`)),
+			StatusCode: http.StatusOK,
+		}, nil).
+		Maybe()
+
+	opts = append([]gql.ClientOption{gql.WithHTTPClient(httpClient), gql.WithSchema(variablesSchema)}, opts...)
+	err := gql.NewClient("test", opts...).Do(req, nil)
+	return variables, err
+}
+
+func (s *SuiteVariables) TestValid() {
+	type filter struct {
+		Role  string   `json:"role"`
+		IDs   []string `json:"ids,omitempty"`
+		Limit *int     `json:"limit,omitempty"`
+	}
+
+	variables, err := s.do(gql.NewRequest(usersQuery,
+		gql.WithVar("filter", filter{Role: "ADMIN", IDs: []string{"1", "2"}}),
+		gql.WithVar("first", 5)))
+	s.Require().NoError(err)
+	s.Equal(map[string]interface{}{
+		"filter": map[string]interface{}{"role": "ADMIN", "ids": []interface{}{"1", "2"}},
+		"first":  float64(5),
+	}, variables)
+}
+
+func (s *SuiteVariables) TestAllErrors() {
+	_, err := s.do(gql.NewRequest(usersQuery,
+		gql.WithVar("filter", map[string]interface{}{
+			"role":    "OWNER",
+			"ids":     []interface{}{"1", nil, true},
+			"unknown": 1,
+		}),
+		gql.WithVar("first", 1.5),
+		gql.WithVar("after", "cursor")))
+
+	var verr *gql.VariablesError
+	s.Require().ErrorAs(err, &verr)
+	var messages []string
+	for _, e := range verr.Errors {
+		messages = append(messages, e.Error())
+	}
+	s.Equal([]string{
+		"$after: variable is not defined by operation Users",
+		`$filter.unknown: unknown field of UserFilter`,
+		`$filter.role: "OWNER" is not a valid Role`,
+		"$filter.ids[1]: must not be null",
+		"$filter.ids[2]: true is not a valid ID",
+		"$first: 1.5 is not a valid Int",
+	}, messages)
+}
+
+func (s *SuiteVariables) TestRequired() {
+	_, err := s.do(gql.NewRequest(usersQuery,
+		gql.WithVar("filter", map[string]interface{}{})))
+
+	var verr *gql.VariablesError
+	s.Require().ErrorAs(err, &verr)
+	s.Require().Len(verr.Errors, 1)
+	s.Equal("$filter.role: must be defined", verr.Errors[0].Error())
+
+	_, err = s.do(gql.NewRequest(usersQuery))
+	s.Require().ErrorAs(err, &verr)
+	s.Equal("$filter: must be defined", verr.Errors[0].Error())
+}
+
+func (s *SuiteVariables) TestListCoercion() {
+	variables, err := s.do(gql.NewRequest(usersQuery,
+		gql.WithVar("filter", map[string]interface{}{"role": "USER", "ids": "1"})))
+	s.Require().NoError(err)
+	s.Equal(map[string]interface{}{"role": "USER", "ids": []interface{}{"1"}}, variables["filter"])
+
+	variables, err = s.do(gql.NewRequest(`query ($ids: [ID!]) { search(ids: $ids) { id } }`,
+		gql.WithVar("ids", 2)))
+	s.Require().NoError(err)
+	s.Equal([]interface{}{float64(2)}, variables["ids"])
+}
+
+func (s *SuiteVariables) TestLargeIntegers() {
+	// Float and ID values are not limited to the range of Int.
+	variables, err := s.do(gql.NewRequest(`query ($p: Float!) { search(price: $p) { id } }`,
+		gql.WithVar("p", 3000000000)))
+	s.Require().NoError(err)
+	s.Equal(float64(3000000000), variables["p"])
+
+	variables, err = s.do(gql.NewRequest(`query ($id: ID!) { search(id: $id) { id } }`,
+		gql.WithVar("id", int64(9007199254740))))
+	s.Require().NoError(err)
+	s.Equal(float64(9007199254740), variables["id"])
+
+	variables, err = s.do(gql.NewRequest(`query ($id: ID!) { search(id: $id) { id } }`,
+		gql.WithVar("id", json.Number("9007199254740"))))
+	s.Require().NoError(err)
+	s.Equal(float64(9007199254740), variables["id"])
+
+	_, err = s.do(gql.NewRequest(usersQuery,
+		gql.WithVar("filter", map[string]interface{}{"role": "USER"}),
+		gql.WithVar("first", int64(3000000000))))
+	var verr *gql.VariablesError
+	s.Require().ErrorAs(err, &verr)
+	s.Equal("$first: 3000000000 is not a valid Int", verr.Errors[0].Error())
+}
+
+// uuid is encoded as a string by its MarshalText method.
+type uuid [16]byte
+
+func (u uuid) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(u[:])), nil
+}
+
+// fullName is encoded as a string by its MarshalJSON method.
+type fullName struct {
+	First, Last string
+}
+
+func (n fullName) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.First + " " + n.Last)
+}
+
+func (s *SuiteVariables) TestCustomEncoding() {
+	variables, err := s.do(gql.NewRequest(`query ($ids: [ID!], $name: String) { search(ids: $ids, name: $name) { id } }`,
+		gql.WithVar("ids", []uuid{{0x12, 0x34}}),
+		gql.WithVar("name", fullName{First: "Bob", Last: "Smith"})))
+	s.Require().NoError(err)
+	s.Equal(map[string]interface{}{
+		"ids":  []interface{}{"12340000000000000000000000000000"},
+		"name": "Bob Smith",
+	}, variables)
+
+	// The encoded value is checked.
+	_, err = s.do(gql.NewRequest(`query ($p: Float) { search(price: $p) { id } }`,
+		gql.WithVar("p", uuid{})))
+	var verr *gql.VariablesError
+	s.Require().ErrorAs(err, &verr)
+	s.Equal(`$p: "00000000000000000000000000000000" is not a valid Float`, verr.Errors[0].Error())
+}
+
+func (s *SuiteVariables) TestScalarEncoder() {
+	createdAfter := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	encodeDate := func(value interface{}) (interface{}, error) {
+		t, ok := value.(time.Time)
+		if !ok {
+			return nil, errors.New("not a time.Time")
+		}
+		return t.Format("2006-01-02"), nil
+	}
+
+	variables, err := s.do(gql.NewRequest(usersQuery,
+		gql.WithVar("filter", map[string]interface{}{"role": "USER", "createdAfter": createdAfter})),
+		gql.WithScalarEncoder("DateTime", encodeDate))
+	s.Require().NoError(err)
+	s.Equal(map[string]interface{}{"role": "USER", "createdAfter": "2021-03-04"}, variables["filter"])
+
+	_, err = s.do(gql.NewRequest(usersQuery,
+		gql.WithVar("filter", map[string]interface{}{"role": "USER", "createdAfter": "yesterday"})),
+		gql.WithScalarEncoder("DateTime", encodeDate))
+	var verr *gql.VariablesError
+	s.Require().ErrorAs(err, &verr)
+	s.Equal("$filter.createdAfter: encode DateTime: not a time.Time", verr.Errors[0].Error())
+}